@@ -0,0 +1,181 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/helpers/json"
+)
+
+// ContentFormat is the format of content for function DecodeContent.
+type ContentFormat string
+
+const (
+	ContentFormatAuto ContentFormat = ""     // Detects the format automatically.
+	ContentFormatJSON ContentFormat = "json" // JSON format.
+	ContentFormatYAML ContentFormat = "yaml" // YAML format, the commonly used subset without anchors and tags.
+	ContentFormatTOML ContentFormat = "toml" // TOML format.
+	ContentFormatINI  ContentFormat = "ini"  // INI format, the sections are decoded as nested maps.
+)
+
+// contentDecoding marks decoding the detected YAML/TOML/INI content automatically, see SetContentDecoding.
+var contentDecoding atomic.Bool
+
+// SetContentDecoding enables or disables decoding the YAML/TOML/INI content of string/[]byte
+// automatically in Map and Struct, the same as the JSON content.
+// It is disabled in default, as the multiple lines common string can also be valid YAML/INI content.
+// The format can also be specified for Map using MapOption.ContentFormat.
+func SetContentDecoding(enabled bool) {
+	contentDecoding.Store(enabled)
+}
+
+// DecodeContent decodes `content` of `format` to map, which is ContentFormatAuto in default.
+// The decoded map can be converted to struct using Struct, the same as map from JSON.
+func DecodeContent(content []byte, format ...ContentFormat) (map[string]interface{}, error) {
+	var usedFormat = ContentFormatAuto
+	if len(format) > 0 {
+		usedFormat = format[0]
+	}
+	if usedFormat == ContentFormatAuto {
+		if usedFormat = DetectContentFormat(content); usedFormat == ContentFormatAuto {
+			return nil, errors.NewCode(codes.CodeInvalidParameter, `unable to detect the content format`)
+		}
+	}
+	var (
+		value interface{}
+		err   error
+	)
+	switch usedFormat {
+	case ContentFormatJSON:
+		err = json.UnmarshalUseNumber(content, &value)
+	case ContentFormatYAML:
+		value, err = parseYAML(content)
+	case ContentFormatTOML:
+		value, err = parseTOML(content)
+	case ContentFormatINI:
+		value, err = parseINI(content)
+	default:
+		return nil, errors.NewCodef(codes.CodeInvalidParameter, `unsupported content format "%s"`, usedFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return map[string]interface{}{}, nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`the %s content should be decoded to map, but got "%T"`,
+			usedFormat, value,
+		)
+	}
+	return m, nil
+}
+
+// StructFromContent decodes `content` of `format` and converts it to struct that `pointer` points to,
+// using the same rules as Struct.
+func StructFromContent(content []byte, pointer interface{}, format ...ContentFormat) error {
+	m, err := DecodeContent(content, format...)
+	if err != nil {
+		return err
+	}
+	return Struct(m, pointer)
+}
+
+// DetectContentFormat detects the format of `content` according to its first significant line,
+// and validates it by parsing. It returns ContentFormatAuto if the format cannot be detected.
+func DetectContentFormat(content []byte) ContentFormat {
+	var trimmed = bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return ContentFormatAuto
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return ContentFormatJSON
+	}
+	var (
+		firstLine  string
+		hasComment bool // INI style comment.
+	)
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ";") {
+			hasComment = true
+		}
+		if firstLine == "" && line != "" && line[0] != '#' && line[0] != ';' {
+			firstLine = line
+		}
+	}
+	switch {
+	case firstLine == "":
+		return ContentFormatAuto
+
+	case firstLine == "---" || isYAMLSequenceItem(firstLine):
+		if _, err := parseYAML(content); err == nil {
+			return ContentFormatYAML
+		}
+
+	case firstLine[0] == '[' || isKeyValueLine(firstLine):
+		if !hasComment {
+			if _, err := parseTOML(content); err == nil {
+				return ContentFormatTOML
+			}
+		}
+		if _, err := parseINI(content); err == nil {
+			return ContentFormatINI
+		}
+
+	default:
+		if _, _, ok := splitYAMLKey(firstLine); ok {
+			if value, err := parseYAML(content); err == nil {
+				if _, ok = value.(map[string]interface{}); ok {
+					return ContentFormatYAML
+				}
+			}
+		}
+	}
+	return ContentFormatAuto
+}
+
+// doDecodeContentCheck checks and decodes `value` to map if it is string/[]byte content of `format`.
+// If `format` is ContentFormatAuto, it decodes the detected YAML/TOML/INI content only if it is
+// enabled by SetContentDecoding, and the single line content is not checked, as it is more likely
+// a common string, like "a=b" or "Error: xxx".
+func doDecodeContentCheck(value interface{}, format ContentFormat) (map[string]interface{}, bool) {
+	var content []byte
+	switch r := value.(type) {
+	case []byte:
+		content = r
+	case string:
+		content = []byte(r)
+	default:
+		return nil, false
+	}
+	if format == ContentFormatAuto {
+		if !contentDecoding.Load() || !bytes.Contains(bytes.TrimSpace(content), []byte{'\n'}) {
+			return nil, false
+		}
+		switch format = DetectContentFormat(content); format {
+		case ContentFormatYAML, ContentFormatTOML, ContentFormatINI:
+		default:
+			return nil, false
+		}
+	}
+	m, err := DecodeContent(content, format)
+	return m, err == nil
+}
+
+// isKeyValueLine checks whether `line` is like "key = value" of TOML/INI, of which the key
+// does not contain ':' that YAML uses.
+func isKeyValueLine(line string) bool {
+	var index = strings.Index(line, "=")
+	return index > 0 && !strings.ContainsAny(line[:index], ":{}[]")
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"strings"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+)
+
+// parseINI parses INI `content` to map[string]interface{}, of which the sections are nested maps.
+// The key and value are separated by '=' or ':', the comments start with ';' or '#', and the values
+// are kept as string, which are converted when they are bound to struct attributes.
+func parseINI(content []byte) (interface{}, error) {
+	var (
+		root    = make(map[string]interface{})
+		current = root
+	)
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			var end = strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, newINIError(i+1, "']' expected for section")
+			}
+			if rest := strings.TrimSpace(line[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, newINIError(i+1, "unexpected content after section")
+			}
+			var name = strings.TrimSpace(line[1:end])
+			if name == "" {
+				return nil, newINIError(i+1, "empty section name")
+			}
+			switch v := root[name].(type) {
+			case nil:
+				current = make(map[string]interface{})
+				root[name] = current
+			case map[string]interface{}:
+				current = v
+			default:
+				return nil, newINIError(i+1, `section "`+name+`" conflicts with key`)
+			}
+			continue
+		}
+		var index = strings.IndexAny(line, "=:")
+		if index <= 0 {
+			return nil, newINIError(i+1, "key-value pair expected")
+		}
+		var (
+			key   = strings.TrimSpace(line[:index])
+			value = strings.TrimSpace(line[index+1:])
+		)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else {
+			// The inline comment should be preceded by whitespace.
+			for _, mark := range []string{" ;", " #", "\t;", "\t#"} {
+				if commentIndex := strings.Index(value, mark); commentIndex >= 0 {
+					value = strings.TrimSpace(value[:commentIndex])
+				}
+			}
+		}
+		current[key] = value
+	}
+	return root, nil
+}
+
+func newINIError(number int, message string) error {
+	return errors.NewCodef(codes.CodeInvalidParameter, `invalid INI content at line %d: %s`, number, message)
+}
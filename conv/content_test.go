@@ -0,0 +1,358 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type contentCase struct {
+	name    string
+	content string
+	expect  map[string]interface{}
+	wantErr bool
+}
+
+func runContentCases(t *testing.T, format ContentFormat, cases []contentCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m, err := DecodeContent([]byte(c.content), format)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expect error, got %#v", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(m, c.expect) {
+				t.Fatalf("\nexpect: %#v\nactual: %#v", c.expect, m)
+			}
+		})
+	}
+}
+
+func TestDecodeContent_YAML(t *testing.T) {
+	runContentCases(t, ContentFormatYAML, []contentCase{
+		{
+			name:    "scalars",
+			content: "s: hello\nq: \"a\\tb\"\nsq: 'it''s'\ni: 42\nneg: -7\nf: 1.5\nb: true\nn: ~\nhex: 0x1f\n",
+			expect: map[string]interface{}{
+				"s": "hello", "q": "a\tb", "sq": "it's", "i": int64(42), "neg": int64(-7),
+				"f": 1.5, "b": true, "n": nil, "hex": int64(31),
+			},
+		},
+		{
+			name:    "nested mapping",
+			content: "server:\n  host: localhost\n  port: 8080\n  tls:\n    enabled: false\n",
+			expect: map[string]interface{}{
+				"server": map[string]interface{}{
+					"host": "localhost",
+					"port": int64(8080),
+					"tls":  map[string]interface{}{"enabled": false},
+				},
+			},
+		},
+		{
+			name:    "sequences",
+			content: "tags:\n  - a\n  - b\nusers:\n  - name: x\n    age: 1\n  - name: y\n    age: 2\n",
+			expect: map[string]interface{}{
+				"tags": []interface{}{"a", "b"},
+				"users": []interface{}{
+					map[string]interface{}{"name": "x", "age": int64(1)},
+					map[string]interface{}{"name": "y", "age": int64(2)},
+				},
+			},
+		},
+		{
+			name:    "flow collections",
+			content: "list: [1, two, \"three\"]\nmap: {a: 1, b: [x, y]}\nempty: []\n",
+			expect: map[string]interface{}{
+				"list":  []interface{}{int64(1), "two", "three"},
+				"map":   map[string]interface{}{"a": int64(1), "b": []interface{}{"x", "y"}},
+				"empty": []interface{}{},
+			},
+		},
+		{
+			name:    "block scalars",
+			content: "literal: |\n  line1\n  line2\nfolded: >\n  a\n  b\nnext: 1\n",
+			expect: map[string]interface{}{
+				"literal": "line1\nline2\n",
+				"folded":  "a b\n",
+				"next":    int64(1),
+			},
+		},
+		{
+			name:    "comments and document start",
+			content: "---\n# comment\na: 1 # trailing\nb: \"# not comment\"\n",
+			expect:  map[string]interface{}{"a": int64(1), "b": "# not comment"},
+		},
+		{
+			name:    "empty content",
+			content: "",
+			expect:  map[string]interface{}{},
+		},
+		{
+			name:    "root sequence",
+			content: "- a\n- b\n",
+			wantErr: true,
+		},
+		{
+			name:    "unclosed flow",
+			content: "a: [1, 2\n",
+			wantErr: true,
+		},
+	})
+}
+
+func TestDecodeContent_YAMLSpecialFloats(t *testing.T) {
+	m, err := DecodeContent([]byte("inf: .inf\nninf: -.Inf\nnan: .nan\n"), ContentFormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsInf(m["inf"].(float64), 1) || !math.IsInf(m["ninf"].(float64), -1) || !math.IsNaN(m["nan"].(float64)) {
+		t.Fatalf("unexpected special floats: %#v", m)
+	}
+}
+
+func TestDecodeContent_TOML(t *testing.T) {
+	runContentCases(t, ContentFormatTOML, []contentCase{
+		{
+			name:    "scalars",
+			content: "s = \"a\\nb\"\nl = 'C:\\path'\ni = 1_000\nhex = 0xff\nbin = 0b101\nf = 3.14\nexp = 1e3\nb = true\n",
+			expect: map[string]interface{}{
+				"s": "a\nb", "l": `C:\path`, "i": int64(1000), "hex": int64(255), "bin": int64(5),
+				"f": 3.14, "exp": 1000.0, "b": true,
+			},
+		},
+		{
+			name:    "tables and dotted keys",
+			content: "title = \"x\"\n[server]\nhost = \"localhost\"\ntls.enabled = true\n[server.limits]\nmax = 10\n",
+			expect: map[string]interface{}{
+				"title": "x",
+				"server": map[string]interface{}{
+					"host":   "localhost",
+					"tls":    map[string]interface{}{"enabled": true},
+					"limits": map[string]interface{}{"max": int64(10)},
+				},
+			},
+		},
+		{
+			name:    "quoted keys",
+			content: "\"a.b\" = 1\nc.\"d.e\" = 2\n",
+			expect: map[string]interface{}{
+				"a.b": int64(1),
+				"c":   map[string]interface{}{"d.e": int64(2)},
+			},
+		},
+		{
+			name:    "arrays and inline tables",
+			content: "ports = [80, 443]\nnested = [[1, 2], [\"a\"]]\npoint = { x = 1, y = 2 }\nmulti = [\n  1, # one\n  2,\n]\n",
+			expect: map[string]interface{}{
+				"ports":  []interface{}{int64(80), int64(443)},
+				"nested": []interface{}{[]interface{}{int64(1), int64(2)}, []interface{}{"a"}},
+				"point":  map[string]interface{}{"x": int64(1), "y": int64(2)},
+				"multi":  []interface{}{int64(1), int64(2)},
+			},
+		},
+		{
+			name:    "array of tables",
+			content: "[[users]]\nname = \"x\"\n[[users]]\nname = \"y\"\n",
+			expect: map[string]interface{}{
+				"users": []interface{}{
+					map[string]interface{}{"name": "x"},
+					map[string]interface{}{"name": "y"},
+				},
+			},
+		},
+		{
+			name:    "multi-line strings",
+			content: "basic = \"\"\"\nline1\nline2\"\"\"\nliteral = '''\nraw\\n'''\n",
+			expect: map[string]interface{}{
+				"basic":   "line1\nline2",
+				"literal": "raw\\n",
+			},
+		},
+		{
+			name:    "local date and time",
+			content: "d = 2024-01-02\nt = 10:20:30\n",
+			expect:  map[string]interface{}{"d": "2024-01-02", "t": "10:20:30"},
+		},
+		{
+			name:    "duplicate key",
+			content: "a = 1\na = 2\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			content: "a =\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated string",
+			content: "a = \"x\n",
+			wantErr: true,
+		},
+	})
+}
+
+func TestDecodeContent_TOMLDateTime(t *testing.T) {
+	m, err := DecodeContent([]byte("at = 2024-01-02T03:04:05Z\n"), ContentFormatTOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expect = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if at, ok := m["at"].(time.Time); !ok || !at.Equal(expect) {
+		t.Fatalf("expect %v, got %#v", expect, m["at"])
+	}
+}
+
+func TestDecodeContent_INI(t *testing.T) {
+	runContentCases(t, ContentFormatINI, []contentCase{
+		{
+			name:    "root keys and sections",
+			content: "name = app\n[db]\nhost = localhost\nport: 3306\n",
+			expect: map[string]interface{}{
+				"name": "app",
+				"db":   map[string]interface{}{"host": "localhost", "port": "3306"},
+			},
+		},
+		{
+			name:    "comments and quotes",
+			content: "; comment\n# comment\n[s] ; section comment\na = 1 ; inline\nb = \"x ; y\"\nc = 'z'\nd = e;f\n",
+			expect: map[string]interface{}{
+				"s": map[string]interface{}{"a": "1", "b": "x ; y", "c": "z", "d": "e;f"},
+			},
+		},
+		{
+			name:    "repeated section",
+			content: "[s]\na = 1\n[t]\nb = 2\n[s]\nc = 3\n",
+			expect: map[string]interface{}{
+				"s": map[string]interface{}{"a": "1", "c": "3"},
+				"t": map[string]interface{}{"b": "2"},
+			},
+		},
+		{
+			name:    "unclosed section",
+			content: "[s\na = 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty section name",
+			content: "[ ]\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing separator",
+			content: "[s]\nnovalue\n",
+			wantErr: true,
+		},
+		{
+			name:    "section conflicts with key",
+			content: "s = 1\n[s]\n",
+			wantErr: true,
+		},
+	})
+}
+
+func TestDetectContentFormat(t *testing.T) {
+	var cases = []struct {
+		content string
+		expect  ContentFormat
+	}{
+		{`{"a": 1}`, ContentFormatJSON},
+		{"a: 1\nb: 2\n", ContentFormatYAML},
+		{"---\na: 1\n", ContentFormatYAML},
+		{"a = 1\n[t]\nb = \"x\"\n", ContentFormatTOML},
+		{"; comment\n[s]\na = 1\n", ContentFormatINI},
+		{"[s]\na = b c\n", ContentFormatINI},
+		{"", ContentFormatAuto},
+		{"just text", ContentFormatAuto},
+	}
+	for _, c := range cases {
+		if format := DetectContentFormat([]byte(c.content)); format != c.expect {
+			t.Errorf("DetectContentFormat(%q): expect %q, got %q", c.content, c.expect, format)
+		}
+	}
+}
+
+func TestMap_ContentNotDetected(t *testing.T) {
+	for _, s := range []string{"Note: hi\nWarning: x", "a=b\nc=d"} {
+		if m := Map(s); m != nil {
+			t.Errorf("Map(%q): expect nil, got %#v", s, m)
+		}
+	}
+}
+
+func TestStructFromContent(t *testing.T) {
+	type Config struct {
+		Name  string
+		Port  int
+		Debug bool
+		Tags  []string
+	}
+	var contents = map[ContentFormat]string{
+		ContentFormatYAML: "name: app\nport: 8080\ndebug: true\ntags: [a, b]\n",
+		ContentFormatTOML: "name = \"app\"\nport = 8080\ndebug = true\ntags = [\"a\", \"b\"]\n",
+		ContentFormatJSON: `{"name": "app", "port": 8080, "debug": true, "tags": ["a", "b"]}`,
+	}
+	var expect = Config{Name: "app", Port: 8080, Debug: true, Tags: []string{"a", "b"}}
+	for format, content := range contents {
+		var config Config
+		if err := StructFromContent([]byte(content), &config, format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(config, expect) {
+			t.Errorf("%s: expect %#v, got %#v", format, expect, config)
+		}
+	}
+}
+
+func TestMap_ContentFormat(t *testing.T) {
+	var expect = map[string]interface{}{"name": "app", "port": int64(8080)}
+	if m := Map("name: app\nport: 8080\n", MapOption{ContentFormat: ContentFormatYAML}); !reflect.DeepEqual(m, expect) {
+		t.Errorf("\nexpect: %#v\nactual: %#v", expect, m)
+	}
+	if m := Map([]byte("name = \"app\"\nport = 8080\n"), MapOption{ContentFormat: ContentFormatTOML}); !reflect.DeepEqual(m, expect) {
+		t.Errorf("\nexpect: %#v\nactual: %#v", expect, m)
+	}
+	if m := Map("{name: app, port: 8080}", MapOption{ContentFormat: ContentFormatYAML}); !reflect.DeepEqual(m, expect) {
+		t.Errorf("\nexpect: %#v\nactual: %#v", expect, m)
+	}
+}
+
+func TestStruct_ContentDecoding(t *testing.T) {
+	type Config struct {
+		Name string
+		Port int
+	}
+	var (
+		content = []byte("name: app\nport: 8080\n")
+		config  Config
+	)
+	if err := Struct(content, &config); err == nil {
+		t.Fatalf("expect error as content decoding is disabled, got %#v", config)
+	}
+	SetContentDecoding(true)
+	defer SetContentDecoding(false)
+	if err := Struct(content, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config != (Config{Name: "app", Port: 8080}) {
+		t.Fatalf("unexpected config: %#v", config)
+	}
+	if m := Map("a: 1\nb: 2"); !reflect.DeepEqual(m, map[string]interface{}{"a": int64(1), "b": int64(2)}) {
+		t.Fatalf("unexpected map: %#v", m)
+	}
+	// The single line content is still not decoded automatically.
+	if m := Map("Note: hi"); m != nil {
+		t.Fatalf("expect nil, got %#v", m)
+	}
+}
@@ -0,0 +1,521 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+)
+
+// tomlParser parses TOML content. The offset date-time values are decoded as time.Time, and the
+// local date-time, local date and local time values are kept as string.
+type tomlParser struct {
+	s       string
+	i       int
+	root    map[string]interface{}
+	current map[string]interface{}
+}
+
+// parseTOML parses TOML `content` to map[string]interface{}.
+func parseTOML(content []byte) (interface{}, error) {
+	p := &tomlParser{
+		s:    strings.ReplaceAll(string(content), "\r\n", "\n"),
+		root: make(map[string]interface{}),
+	}
+	p.current = p.root
+	for {
+		p.skipBlank()
+		if p.i >= len(p.s) {
+			return p.root, nil
+		}
+		var err error
+		if p.s[p.i] == '[' {
+			err = p.parseTableHeader()
+		} else {
+			err = p.parseKeyValue(p.current)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = p.parseLineEnd(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// skipBlank skips the whitespaces, line breaks and comments.
+func (p *tomlParser) skipBlank() {
+	for p.i < len(p.s) {
+		switch p.s[p.i] {
+		case ' ', '\t', '\n':
+			p.i++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipSpace() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	for p.i < len(p.s) && p.s[p.i] != '\n' {
+		p.i++
+	}
+}
+
+// parseLineEnd parses the end of line, which might contain comment.
+func (p *tomlParser) parseLineEnd() error {
+	p.skipSpace()
+	if p.i < len(p.s) && p.s[p.i] == '#' {
+		p.skipComment()
+	}
+	if p.i < len(p.s) && p.s[p.i] != '\n' {
+		return p.newError("line break expected")
+	}
+	return nil
+}
+
+// parseTableHeader parses the table header "[table]" or array of tables header "[[table]]".
+func (p *tomlParser) parseTableHeader() error {
+	var isArray = strings.HasPrefix(p.s[p.i:], "[[")
+	if isArray {
+		p.i += 2
+	} else {
+		p.i++
+	}
+	keys, err := p.parseKeys()
+	if err != nil {
+		return err
+	}
+	var closing = "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.s[p.i:], closing) {
+		return p.newError("'" + closing + "' expected for table header")
+	}
+	p.i += len(closing)
+	parent, err := p.getTable(p.root, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	var (
+		lastKey  = keys[len(keys)-1]
+		existing = parent[lastKey]
+	)
+	if isArray {
+		var tables []interface{}
+		switch v := existing.(type) {
+		case nil:
+		case []interface{}:
+			tables = v
+		default:
+			return p.newError(`key "` + lastKey + `" is already defined`)
+		}
+		p.current = make(map[string]interface{})
+		parent[lastKey] = append(tables, p.current)
+		return nil
+	}
+	switch v := existing.(type) {
+	case nil:
+		p.current = make(map[string]interface{})
+		parent[lastKey] = p.current
+	case map[string]interface{}:
+		p.current = v
+	default:
+		return p.newError(`key "` + lastKey + `" is already defined`)
+	}
+	return nil
+}
+
+// getTable retrieves the table of `keys` from `table`, it creates the tables if necessary.
+// The last table of array of tables is used if the value of key is array of tables.
+func (p *tomlParser) getTable(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		switch v := table[key].(type) {
+		case nil:
+			var newTable = make(map[string]interface{})
+			table[key] = newTable
+			table = newTable
+		case map[string]interface{}:
+			table = v
+		case []interface{}:
+			if len(v) == 0 {
+				return nil, p.newError(`key "` + key + `" is not a table`)
+			}
+			last, ok := v[len(v)-1].(map[string]interface{})
+			if !ok {
+				return nil, p.newError(`key "` + key + `" is not a table`)
+			}
+			table = last
+		default:
+			return nil, p.newError(`key "` + key + `" is not a table`)
+		}
+	}
+	return table, nil
+}
+
+// parseKeyValue parses "key = value" into `table`, the key might be dotted keys.
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+	keys, err := p.parseKeys()
+	if err != nil {
+		return err
+	}
+	if p.i >= len(p.s) || p.s[p.i] != '=' {
+		return p.newError("'=' expected after key")
+	}
+	p.i++
+	p.skipSpace()
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	if table, err = p.getTable(table, keys[:len(keys)-1]); err != nil {
+		return err
+	}
+	var lastKey = keys[len(keys)-1]
+	if _, ok := table[lastKey]; ok {
+		return p.newError(`key "` + lastKey + `" is already defined`)
+	}
+	table[lastKey] = value
+	return nil
+}
+
+// parseKeys parses the dotted keys like `a."b.c".d`.
+func (p *tomlParser) parseKeys() ([]string, error) {
+	var keys = make([]string, 0, 1)
+	for {
+		p.skipSpace()
+		if p.i >= len(p.s) {
+			return nil, p.newError("key expected")
+		}
+		var key string
+		switch p.s[p.i] {
+		case '"':
+			value, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = value
+		case '\'':
+			value, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = value
+		default:
+			var start = p.i
+			for p.i < len(p.s) && isTOMLBareKeyChar(p.s[p.i]) {
+				p.i++
+			}
+			if start == p.i {
+				return nil, p.newError("key expected")
+			}
+			key = p.s[start:p.i]
+		}
+		keys = append(keys, key)
+		p.skipSpace()
+		if p.i >= len(p.s) || p.s[p.i] != '.' {
+			return keys, nil
+		}
+		p.i++
+	}
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	if p.i >= len(p.s) {
+		return nil, p.newError("value expected")
+	}
+	switch {
+	case strings.HasPrefix(p.s[p.i:], `"""`):
+		return p.parseMultiLineString(`"""`)
+	case strings.HasPrefix(p.s[p.i:], `'''`):
+		return p.parseMultiLineString(`'''`)
+	case p.s[p.i] == '"':
+		return p.parseBasicString()
+	case p.s[p.i] == '\'':
+		return p.parseLiteralString()
+	case p.s[p.i] == '[':
+		return p.parseArray()
+	case p.s[p.i] == '{':
+		return p.parseInlineTable()
+	}
+	var start = p.i
+	for p.i < len(p.s) && strings.IndexByte(" \t\n,]}#", p.s[p.i]) < 0 {
+		p.i++
+	}
+	var text = p.s[start:p.i]
+	// The date-time might use space as delimiter, eg: "1979-05-27 07:32:00Z".
+	if len(text) == 10 && text[4] == '-' && text[7] == '-' &&
+		p.i+3 < len(p.s) && p.s[p.i] == ' ' && isDigit(p.s[p.i+1]) && isDigit(p.s[p.i+2]) && p.s[p.i+3] == ':' {
+		p.i++
+		for p.i < len(p.s) && strings.IndexByte(" \t\n,]}#", p.s[p.i]) < 0 {
+			p.i++
+		}
+		text = strings.Replace(p.s[start:p.i], " ", "T", 1)
+	}
+	if value, ok := parseTOMLScalar(text); ok {
+		return value, nil
+	}
+	return nil, p.newError(`invalid value "` + text + `"`)
+}
+
+func (p *tomlParser) parseArray() (interface{}, error) {
+	p.i++
+	var list = make([]interface{}, 0)
+	for {
+		p.skipBlank()
+		if p.i >= len(p.s) {
+			return nil, p.newError("']' expected for array")
+		}
+		if p.s[p.i] == ']' {
+			p.i++
+			return list, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		p.skipBlank()
+		if p.i < len(p.s) && p.s[p.i] == ',' {
+			p.i++
+			continue
+		}
+		if p.i >= len(p.s) || p.s[p.i] != ']' {
+			return nil, p.newError("',' or ']' expected in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	p.i++
+	var table = make(map[string]interface{})
+	p.skipSpace()
+	if p.i < len(p.s) && p.s[p.i] == '}' {
+		p.i++
+		return table, nil
+	}
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.i >= len(p.s) {
+			return nil, p.newError("'}' expected for inline table")
+		}
+		switch p.s[p.i] {
+		case ',':
+			p.i++
+		case '}':
+			p.i++
+			return table, nil
+		default:
+			return nil, p.newError("',' or '}' expected in inline table")
+		}
+	}
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	var builder strings.Builder
+	for p.i++; p.i < len(p.s); p.i++ {
+		switch c := p.s[p.i]; c {
+		case '"':
+			p.i++
+			return builder.String(), nil
+		case '\n':
+			return "", p.newError("unclosed string")
+		case '\\':
+			if err := p.parseEscape(&builder); err != nil {
+				return "", err
+			}
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return "", p.newError("unclosed string")
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	var start = p.i + 1
+	for p.i++; p.i < len(p.s); p.i++ {
+		switch p.s[p.i] {
+		case '\'':
+			p.i++
+			return p.s[start : p.i-1], nil
+		case '\n':
+			return "", p.newError("unclosed string")
+		}
+	}
+	return "", p.newError("unclosed string")
+}
+
+// parseMultiLineString parses the multi-line basic string or literal string, of which the delimiter is `quote`.
+func (p *tomlParser) parseMultiLineString(quote string) (string, error) {
+	p.i += 3
+	// The line break immediately following the opening delimiter is trimmed.
+	if p.i < len(p.s) && p.s[p.i] == '\n' {
+		p.i++
+	}
+	var builder strings.Builder
+	for p.i < len(p.s) {
+		if strings.HasPrefix(p.s[p.i:], quote) {
+			p.i += 3
+			// At most two additional quotes are allowed right before the closing delimiter.
+			for k := 0; k < 2 && p.i < len(p.s) && p.s[p.i] == quote[0]; k++ {
+				builder.WriteByte(quote[0])
+				p.i++
+			}
+			return builder.String(), nil
+		}
+		var c = p.s[p.i]
+		if c == '\\' && quote == `"""` {
+			// The line ending backslash trims all whitespaces and line breaks after it.
+			var j = p.i + 1
+			for j < len(p.s) && (p.s[j] == ' ' || p.s[j] == '\t') {
+				j++
+			}
+			if j < len(p.s) && p.s[j] == '\n' {
+				for j < len(p.s) && strings.IndexByte(" \t\n", p.s[j]) >= 0 {
+					j++
+				}
+				p.i = j
+				continue
+			}
+			if err := p.parseEscape(&builder); err != nil {
+				return "", err
+			}
+			p.i++
+			continue
+		}
+		builder.WriteByte(c)
+		p.i++
+	}
+	return "", p.newError("unclosed multi-line string")
+}
+
+// parseEscape parses the escape sequence at current position, which is the char '\'.
+// The position is moved to the last char of the escape sequence.
+func (p *tomlParser) parseEscape(builder *strings.Builder) error {
+	p.i++
+	if p.i >= len(p.s) {
+		return p.newError("invalid escape sequence")
+	}
+	switch c := p.s[p.i]; c {
+	case 'b':
+		builder.WriteByte('\b')
+	case 't':
+		builder.WriteByte('\t')
+	case 'n':
+		builder.WriteByte('\n')
+	case 'f':
+		builder.WriteByte('\f')
+	case 'r':
+		builder.WriteByte('\r')
+	case 'e':
+		builder.WriteByte(0x1b)
+	case '"', '\\':
+		builder.WriteByte(c)
+	case 'u', 'U':
+		var size = 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.i+size >= len(p.s) {
+			return p.newError("invalid unicode escape sequence")
+		}
+		code, err := strconv.ParseUint(p.s[p.i+1:p.i+1+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.newError("invalid unicode escape sequence")
+		}
+		builder.WriteRune(rune(code))
+		p.i += size
+	default:
+		return p.newError("invalid escape sequence")
+	}
+	return nil
+}
+
+func (p *tomlParser) newError(message string) error {
+	var line = strings.Count(p.s[:min(p.i, len(p.s))], "\n") + 1
+	return errors.NewCodef(codes.CodeInvalidParameter, `invalid TOML content at line %d: %s`, line, message)
+}
+
+// parseTOMLScalar parses the boolean, number and date-time value `text`.
+func parseTOMLScalar(text string) (interface{}, bool) {
+	switch text {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "inf", "+inf":
+		return math.Inf(1), true
+	case "-inf":
+		return math.Inf(-1), true
+	case "nan", "+nan", "-nan":
+		return math.NaN(), true
+	case "":
+		return nil, false
+	}
+	var number = strings.ReplaceAll(text, "_", "")
+	if len(number) > 2 && number[0] == '0' {
+		var base = 0
+		switch number[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base > 0 {
+			v, err := strconv.ParseInt(number[2:], base, 64)
+			return v, err == nil
+		}
+	}
+	if v, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return v, true
+	}
+	if isDigit(text[len(text)-1]) && (isDigit(text[0]) || text[0] == '-' || text[0] == '+') &&
+		!strings.ContainsAny(text, ":T") && strings.Count(text, "-") <= 2 && !isTOMLDate(text) {
+		if v, err := strconv.ParseFloat(number, 64); err == nil {
+			return v, true
+		}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+		return t, true
+	}
+	// Local date-time, local date and local time.
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02", "15:04:05.999999999"} {
+		if _, err := time.Parse(layout, text); err == nil {
+			return text, true
+		}
+	}
+	return nil, false
+}
+
+func isTOMLDate(text string) bool {
+	return len(text) >= 10 && text[4] == '-' && text[7] == '-'
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c == '_' || c == '-' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,560 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+)
+
+var (
+	yamlIntRegex   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloatRegex = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// yamlLine is a line of YAML content.
+type yamlLine struct {
+	Number int    // Line number starting from 1.
+	Indent int    // Count of leading spaces.
+	Text   string // Trimmed content without comment, it is empty for blank line.
+	Raw    string // Original content without line break, for block scalars.
+}
+
+// yamlParser parses the commonly used subset of YAML: block mappings and sequences, flow collections,
+// plain/quoted/block scalars and comments. The anchors, aliases, tags and multiple documents are not supported.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseYAML parses YAML `content` to map[string]interface{}/[]interface{}/scalar value.
+func parseYAML(content []byte) (interface{}, error) {
+	var (
+		p       = &yamlParser{}
+		started = false
+	)
+	for i, raw := range strings.Split(string(content), "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		var trimmed = strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, newYAMLError(i+1, "tab character is not allowed for indentation")
+		}
+		if len(trimmed) == len(raw) && (trimmed == "---" || strings.HasPrefix(trimmed, "--- ")) {
+			if started {
+				// Only the first document is parsed.
+				break
+			}
+			if trimmed = strings.TrimSpace(trimmed[3:]); trimmed == "" {
+				continue
+			}
+		}
+		if len(trimmed) == len(raw) && trimmed == "..." {
+			break
+		}
+		var text = stripYAMLComment(trimmed)
+		if text != "" {
+			started = true
+		}
+		p.lines = append(p.lines, yamlLine{
+			Number: i + 1,
+			Indent: len(raw) - len(trimmed),
+			Text:   text,
+			Raw:    raw,
+		})
+	}
+	if !p.skipBlank() {
+		return nil, nil
+	}
+	value, err := p.parseBlock(p.lines[p.pos].Indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.skipBlank() {
+		return nil, newYAMLError(p.lines[p.pos].Number, "unexpected content")
+	}
+	return value, nil
+}
+
+// skipBlank skips the blank lines, it returns false if there's no more lines.
+func (p *yamlParser) skipBlank() bool {
+	for p.pos < len(p.lines) && p.lines[p.pos].Text == "" {
+		p.pos++
+	}
+	return p.pos < len(p.lines)
+}
+
+// parseBlock parses the block node at current line, of which the indent is `indent`.
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	var line = p.lines[p.pos]
+	if isYAMLSequenceItem(line.Text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitYAMLKey(line.Text); ok {
+		return p.parseMapping(indent)
+	}
+	p.pos++
+	return p.parseInlineValue(line.Text, indent-1, line.Number)
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	var list = make([]interface{}, 0)
+	for p.skipBlank() {
+		var line = p.lines[p.pos]
+		if line.Indent < indent {
+			break
+		}
+		if line.Indent > indent {
+			return nil, newYAMLError(line.Number, "bad indentation of sequence item")
+		}
+		if !isYAMLSequenceItem(line.Text) {
+			break
+		}
+		var rest = strings.TrimSpace(line.Text[1:])
+		if rest == "" {
+			p.pos++
+			var item interface{}
+			if p.skipBlank() && p.lines[p.pos].Indent > indent {
+				var err error
+				if item, err = p.parseBlock(p.lines[p.pos].Indent); err != nil {
+					return nil, err
+				}
+			}
+			list = append(list, item)
+			continue
+		}
+		if _, _, ok := splitYAMLKey(rest); ok || isYAMLSequenceItem(rest) {
+			// The nested block node starts in the same line, like "- key: value".
+			var nestedIndent = indent + len(line.Text) - len(rest)
+			p.lines[p.pos] = yamlLine{
+				Number: line.Number,
+				Indent: nestedIndent,
+				Text:   rest,
+				Raw:    strings.Repeat(" ", nestedIndent) + rest,
+			}
+			item, err := p.parseBlock(nestedIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+			continue
+		}
+		p.pos++
+		item, err := p.parseInlineValue(rest, indent, line.Number)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	var m = make(map[string]interface{})
+	for p.skipBlank() {
+		var line = p.lines[p.pos]
+		if line.Indent < indent {
+			break
+		}
+		if line.Indent > indent {
+			return nil, newYAMLError(line.Number, "bad indentation of mapping entry")
+		}
+		key, rest, ok := splitYAMLKey(line.Text)
+		if !ok {
+			if isYAMLSequenceItem(line.Text) {
+				break
+			}
+			return nil, newYAMLError(line.Number, "mapping entry expected")
+		}
+		p.pos++
+		var (
+			value interface{}
+			err   error
+		)
+		if rest == "" {
+			if p.skipBlank() {
+				var next = p.lines[p.pos]
+				switch {
+				case next.Indent > indent:
+					value, err = p.parseBlock(next.Indent)
+				case next.Indent == indent && isYAMLSequenceItem(next.Text):
+					// The sequence might have the same indent as its key.
+					value, err = p.parseSequence(indent)
+				}
+			}
+		} else {
+			value, err = p.parseInlineValue(rest, indent, line.Number)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// parseInlineValue parses the value `text` in current line, which might be block scalar or flow
+// collection that spans the following lines with indent greater than `parentIndent`.
+func (p *yamlParser) parseInlineValue(text string, parentIndent int, number int) (interface{}, error) {
+	switch text[0] {
+	case '|', '>':
+		return p.parseBlockScalar(text, parentIndent, number)
+	case '[', '{':
+		for !isYAMLFlowClosed(text) && p.pos < len(p.lines) && p.lines[p.pos].Indent > parentIndent {
+			text += " " + p.lines[p.pos].Text
+			p.pos++
+		}
+		fp := &yamlFlowParser{s: text, number: number}
+		value, err := fp.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if fp.skipSpace(); fp.i < len(fp.s) {
+			return nil, newYAMLError(number, "unexpected content after flow collection")
+		}
+		return value, nil
+	case '&', '*', '!':
+		return nil, newYAMLError(number, "anchors, aliases and tags are not supported")
+	}
+	return parseYAMLScalar(text), nil
+}
+
+// parseBlockScalar parses the literal "|" or folded ">" block scalar.
+func (p *yamlParser) parseBlockScalar(header string, parentIndent int, number int) (interface{}, error) {
+	var (
+		folded   = header[0] == '>'
+		chomping = byte(0)
+		lines    = make([]string, 0)
+		indent   = -1
+	)
+	for _, c := range header[1:] {
+		switch {
+		case c == '-' || c == '+':
+			chomping = byte(c)
+		case c >= '1' && c <= '9':
+			indent = parentIndent + int(c-'0')
+			if parentIndent < 0 {
+				indent = int(c - '0')
+			}
+		case c == ' ':
+		default:
+			return nil, newYAMLError(number, "invalid block scalar header")
+		}
+	}
+	for ; p.pos < len(p.lines); p.pos++ {
+		var line = p.lines[p.pos]
+		if strings.TrimSpace(line.Raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if line.Indent <= parentIndent {
+			break
+		}
+		if indent < 0 {
+			indent = line.Indent
+		}
+		if line.Indent < indent {
+			break
+		}
+		lines = append(lines, line.Raw[indent:])
+	}
+	// The trailing blank lines are handled by chomping.
+	var trailing = 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	var builder strings.Builder
+	for i, line := range lines {
+		switch {
+		case i == 0:
+		case !folded || line == "":
+			builder.WriteByte('\n')
+		case lines[i-1] == "":
+			// The line breaks are already written for the blank lines.
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(lines[i-1], " "):
+			// The more-indented lines are not folded.
+			builder.WriteByte('\n')
+		default:
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(line)
+	}
+	if len(lines) > 0 {
+		switch chomping {
+		case '-':
+		case '+':
+			builder.WriteString(strings.Repeat("\n", trailing+1))
+		default:
+			builder.WriteByte('\n')
+		}
+	}
+	return builder.String(), nil
+}
+
+// yamlFlowParser parses the flow collection like `[a, b]` or `{a: 1, b: 2}`.
+type yamlFlowParser struct {
+	s      string
+	i      int
+	number int
+}
+
+func (p *yamlFlowParser) skipSpace() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *yamlFlowParser) parseValue() (interface{}, error) {
+	p.skipSpace()
+	if p.i >= len(p.s) {
+		return nil, newYAMLError(p.number, "unexpected end of flow collection")
+	}
+	switch p.s[p.i] {
+	case '[':
+		p.i++
+		var list = make([]interface{}, 0)
+		for {
+			p.skipSpace()
+			if p.i < len(p.s) && p.s[p.i] == ']' {
+				p.i++
+				return list, nil
+			}
+			item, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+			if err = p.parseSeparator(']'); err != nil {
+				return nil, err
+			}
+		}
+
+	case '{':
+		p.i++
+		var m = make(map[string]interface{})
+		for {
+			p.skipSpace()
+			if p.i < len(p.s) && p.s[p.i] == '}' {
+				p.i++
+				return m, nil
+			}
+			key, err := p.parseScalar(true)
+			if err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			var value interface{}
+			if p.i < len(p.s) && p.s[p.i] == ':' {
+				p.i++
+				if value, err = p.parseValue(); err != nil {
+					return nil, err
+				}
+			}
+			m[String(key)] = value
+			if err = p.parseSeparator('}'); err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		return p.parseScalar(false)
+	}
+}
+
+// parseSeparator parses ',' or the closing char `end` of flow collection, the `end` is not consumed.
+func (p *yamlFlowParser) parseSeparator(end byte) error {
+	p.skipSpace()
+	if p.i < len(p.s) {
+		switch p.s[p.i] {
+		case ',':
+			p.i++
+			return nil
+		case end:
+			return nil
+		}
+	}
+	return newYAMLError(p.number, "',' or '"+string(end)+"' expected in flow collection")
+}
+
+// parseScalar parses the scalar in flow collection, the plain scalar ends with ',', ']', '}',
+// or ':' if it is a mapping key.
+func (p *yamlFlowParser) parseScalar(isKey bool) (interface{}, error) {
+	p.skipSpace()
+	var start = p.i
+	if p.i < len(p.s) && (p.s[p.i] == '"' || p.s[p.i] == '\'') {
+		var quote = p.s[p.i]
+		for p.i++; p.i < len(p.s); p.i++ {
+			if p.s[p.i] == '\\' && quote == '"' {
+				p.i++
+				continue
+			}
+			if p.s[p.i] == quote {
+				if quote == '\'' && p.i+1 < len(p.s) && p.s[p.i+1] == '\'' {
+					p.i++
+					continue
+				}
+				p.i++
+				return parseYAMLScalar(p.s[start:p.i]), nil
+			}
+		}
+		return nil, newYAMLError(p.number, "unclosed quoted string in flow collection")
+	}
+	for p.i < len(p.s) {
+		var c = p.s[p.i]
+		if c == ',' || c == ']' || c == '}' || (isKey && c == ':') {
+			break
+		}
+		p.i++
+	}
+	var text = strings.TrimSpace(p.s[start:p.i])
+	if isKey {
+		return text, nil
+	}
+	return parseYAMLScalar(text), nil
+}
+
+// parseYAMLScalar parses the scalar `text` to string/int64/float64/bool/nil.
+func parseYAMLScalar(text string) interface{} {
+	if len(text) >= 2 {
+		switch {
+		case text[0] == '"' && text[len(text)-1] == '"':
+			if s, err := strconv.Unquote(text); err == nil {
+				return s
+			}
+			return text[1 : len(text)-1]
+		case text[0] == '\'' && text[len(text)-1] == '\'':
+			return strings.ReplaceAll(text[1:len(text)-1], "''", "'")
+		}
+	}
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1)
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1)
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN()
+	}
+	switch {
+	case yamlIntRegex.MatchString(text):
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	case strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0o"):
+		var base = 16
+		if text[1] == 'o' {
+			base = 8
+		}
+		if v, err := strconv.ParseInt(text[2:], base, 64); err == nil {
+			return v
+		}
+	}
+	if yamlFloatRegex.MatchString(text) {
+		if v, err := strconv.ParseFloat(text, 64); err == nil {
+			return v
+		}
+	}
+	return text
+}
+
+// splitYAMLKey splits mapping entry `text` to key and value text.
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	if text == "" || isYAMLSequenceItem(text) || strings.IndexByte("[{#&*!|>%@`", text[0]) >= 0 {
+		return "", "", false
+	}
+	var index int
+	if text[0] == '"' || text[0] == '\'' {
+		var end = strings.IndexByte(text[1:], text[0])
+		if end < 0 {
+			return "", "", false
+		}
+		index = end + 2
+		if index >= len(text) || text[index] != ':' {
+			return "", "", false
+		}
+		key = String(parseYAMLScalar(text[:index]))
+	} else {
+		for index = strings.IndexByte(text, ':'); index >= 0; {
+			if index == len(text)-1 || text[index+1] == ' ' {
+				break
+			}
+			next := strings.IndexByte(text[index+1:], ':')
+			if next < 0 {
+				return "", "", false
+			}
+			index += next + 1
+		}
+		if index <= 0 {
+			return "", "", false
+		}
+		key = strings.TrimSpace(text[:index])
+	}
+	return key, strings.TrimSpace(text[index+1:]), true
+}
+
+// stripYAMLComment removes the comment of line `text`, the '#' in quoted string is not a comment.
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		var c = text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimSpace(text[:i])
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" :-[{,", text[i-1]) >= 0):
+			quote = c
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// isYAMLFlowClosed checks whether the brackets of flow collection `text` are closed.
+func isYAMLFlowClosed(text string) bool {
+	var (
+		depth = 0
+		quote byte
+	)
+	for i := 0; i < len(text); i++ {
+		var c = text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func newYAMLError(number int, message string) error {
+	return errors.NewCodef(codes.CodeInvalidParameter, `invalid YAML content at line %d: %s`, number, message)
+}
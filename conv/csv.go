@@ -0,0 +1,356 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/utils/tag"
+)
+
+// CSVOption specifies the option for CSV/TSV encoding and decoding.
+type CSVOption struct {
+	// Comma is the field delimiter, it is ',' for CSV and '\t' for TSV in default.
+	Comma rune
+
+	// Columns specifies the columns and their order for encoding, which are attribute names or tag names
+	// of struct, or keys of map. In default, the columns are the attributes of struct in declaration
+	// order, or the keys of all maps in ascending order.
+	Columns []string
+
+	// TimeFormat specifies the layout for time.Time values in encoding, eg: time.RFC3339.
+	// The time.Time values are converted using String in default.
+	TimeFormat string
+
+	// FloatFormat specifies the format for float values in encoding, see strconv.FormatFloat.
+	// The float values are converted using String in default.
+	FloatFormat byte
+
+	// FloatPrecision specifies the precision for float values if FloatFormat is specified,
+	// see strconv.FormatFloat.
+	FloatPrecision int
+}
+
+// csvColumn is the struct attribute of encoding column.
+type csvColumn struct {
+	Name  string // Column name.
+	Index []int  // Index sequence of struct attribute, it is nil if no attribute for the column.
+}
+
+// CSVDecode reads CSV content from `reader` and converts the rows into slice that `pointer` points to.
+// The first row of the content is the header, of which the columns are matched to struct attributes by
+// attribute names or tag names in tag.StructTagPriority, like Struct.
+//
+// The parameter `pointer` should be type of *[]struct/*[]*struct/*[]map[string]string/*[]map[string]interface{}.
+// It reads the rows one by one, and the empty cells are ignored for struct element.
+func CSVDecode(reader io.Reader, pointer interface{}, option ...CSVOption) error {
+	var usedOption CSVOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	if usedOption.Comma == 0 {
+		usedOption.Comma = ','
+	}
+	return doCSVDecode(reader, pointer, usedOption)
+}
+
+// TSVDecode acts as CSVDecode, but the field delimiter is '\t' in default.
+func TSVDecode(reader io.Reader, pointer interface{}, option ...CSVOption) error {
+	var usedOption CSVOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	if usedOption.Comma == 0 {
+		usedOption.Comma = '\t'
+	}
+	return doCSVDecode(reader, pointer, usedOption)
+}
+
+// CSVEncode converts `list` to CSV content and writes it to `writer` row by row, the first row is the header.
+// The parameter `list` should be type of slice of struct/*struct/map.
+//
+// The struct attributes are named by tag names in tag.StructTagPriority or attribute names, and the
+// values are converted using String, except the time.Time and float values that can be formatted by option.
+func CSVEncode(writer io.Writer, list interface{}, option ...CSVOption) error {
+	var usedOption CSVOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	if usedOption.Comma == 0 {
+		usedOption.Comma = ','
+	}
+	return doCSVEncode(writer, list, usedOption)
+}
+
+// TSVEncode acts as CSVEncode, but the field delimiter is '\t' in default.
+func TSVEncode(writer io.Writer, list interface{}, option ...CSVOption) error {
+	var usedOption CSVOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	if usedOption.Comma == 0 {
+		usedOption.Comma = '\t'
+	}
+	return doCSVEncode(writer, list, usedOption)
+}
+
+func doCSVDecode(reader io.Reader, pointer interface{}, option CSVOption) (err error) {
+	var pointerRv = reflect.ValueOf(pointer)
+	if pointerRv.Kind() != reflect.Ptr || pointerRv.IsNil() || pointerRv.Elem().Kind() != reflect.Slice {
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid parameter type "%T", should be type of pointer to slice`,
+			pointer,
+		)
+	}
+	var (
+		sliceRv   = pointerRv.Elem()
+		elemType  = sliceRv.Type().Elem()
+		csvReader = csv.NewReader(reader)
+		header    []string
+		record    []string
+	)
+	csvReader.Comma = option.Comma
+	csvReader.ReuseRecord = true
+	if header, err = csvReader.Read(); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return errors.Wrap(err, `read CSV header failed`)
+	}
+	header = append([]string{}, header...)
+	if len(header) > 0 {
+		// Remove the UTF-8 BOM that might be written by some spreadsheet applications.
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	var isStructElem = elemType.Kind() == reflect.Struct ||
+		(elemType.Kind() == reflect.Ptr && elemType.Elem().Kind() == reflect.Struct)
+	for row := 1; ; row++ {
+		if record, err = csvReader.Read(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errors.Wrapf(err, `read CSV row %d failed`, row)
+		}
+		var data = make(map[string]interface{}, len(header))
+		for i, name := range header {
+			if i >= len(record) || (isStructElem && record[i] == "") {
+				continue
+			}
+			data[name] = record[i]
+		}
+		var elemPtr = reflect.New(elemType)
+		if err = Scan(data, elemPtr.Interface()); err != nil {
+			return errors.Wrapf(err, `decode CSV row %d failed`, row)
+		}
+		sliceRv.Set(reflect.Append(sliceRv, elemPtr.Elem()))
+	}
+}
+
+func doCSVEncode(writer io.Writer, list interface{}, option CSVOption) error {
+	var listRv = reflect.ValueOf(list)
+	for listRv.Kind() == reflect.Ptr && !listRv.IsNil() {
+		listRv = listRv.Elem()
+	}
+	if listRv.Kind() != reflect.Slice && listRv.Kind() != reflect.Array {
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid parameter type "%T", should be type of slice`,
+			list,
+		)
+	}
+	var (
+		csvWriter  = csv.NewWriter(writer)
+		columns    []csvColumn
+		structType reflect.Type
+	)
+	csvWriter.Comma = option.Comma
+	var elemType = listRv.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	switch elemType.Kind() {
+	case reflect.Struct:
+		structType = elemType
+		columns = getCSVStructColumns(structType, option.Columns)
+	case reflect.Map, reflect.Interface:
+		columns = getCSVMapColumns(listRv, option.Columns)
+	default:
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid element type "%s", should be type of struct/*struct/map`,
+			elemType.String(),
+		)
+	}
+	var record = make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.Name
+	}
+	if err := csvWriter.Write(record); err != nil {
+		return err
+	}
+	for i := 0; i < listRv.Len(); i++ {
+		var itemRv = listRv.Index(i)
+		for itemRv.Kind() == reflect.Ptr || itemRv.Kind() == reflect.Interface {
+			if itemRv.IsNil() {
+				break
+			}
+			itemRv = itemRv.Elem()
+		}
+		for j, column := range columns {
+			var cellRv reflect.Value
+			switch {
+			case itemRv.Kind() == reflect.Struct && itemRv.Type() == structType && column.Index != nil:
+				cellRv = csvStructFieldByIndex(itemRv, column.Index)
+			case itemRv.Kind() == reflect.Map && itemRv.Type().Key().Kind() == reflect.String:
+				cellRv = itemRv.MapIndex(reflect.ValueOf(column.Name).Convert(itemRv.Type().Key()))
+			}
+			record[j] = formatCSVValue(cellRv, option)
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// getCSVStructColumns returns the encoding columns of struct type `structType`.
+// If `names` is given, the columns are matched by attribute names or tag names.
+func getCSVStructColumns(structType reflect.Type, names []string) []csvColumn {
+	var columns = make([]csvColumn, 0)
+	doGetCSVStructColumns(structType, nil, &columns)
+	if len(names) == 0 {
+		return columns
+	}
+	var selected = make([]csvColumn, 0, len(names))
+	for _, name := range names {
+		var column = csvColumn{Name: name}
+		for _, c := range columns {
+			if c.Name == name || structType.FieldByIndex(c.Index).Name == name {
+				column.Index = c.Index
+				break
+			}
+		}
+		selected = append(selected, column)
+	}
+	return selected
+}
+
+func doGetCSVStructColumns(structType reflect.Type, parentIndex []int, columns *[]csvColumn) {
+	for i := 0; i < structType.NumField(); i++ {
+		var (
+			field   = structType.Field(i)
+			index   = append(append([]int{}, parentIndex...), i)
+			tagName = getTagNameFromField(field, tag.StructTagPriority)
+		)
+		if tagName == "-" {
+			continue
+		}
+		if field.Anonymous && tagName == "" {
+			var embeddedType = field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				doGetCSVStructColumns(embeddedType, index, columns)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if tagName == "" {
+			tagName = field.Name
+		}
+		*columns = append(*columns, csvColumn{Name: tagName, Index: index})
+	}
+}
+
+// getCSVMapColumns returns the encoding columns of map list `listRv`.
+// If `names` is not given, the columns are the keys of all maps in ascending order.
+func getCSVMapColumns(listRv reflect.Value, names []string) []csvColumn {
+	var columns = make([]csvColumn, 0, len(names))
+	if len(names) == 0 {
+		var keySet = make(map[string]struct{})
+		for i := 0; i < listRv.Len(); i++ {
+			var itemRv = listRv.Index(i)
+			for itemRv.Kind() == reflect.Ptr || itemRv.Kind() == reflect.Interface {
+				if itemRv.IsNil() {
+					break
+				}
+				itemRv = itemRv.Elem()
+			}
+			if itemRv.Kind() != reflect.Map || itemRv.Type().Key().Kind() != reflect.String {
+				continue
+			}
+			for _, key := range itemRv.MapKeys() {
+				keySet[key.String()] = struct{}{}
+			}
+		}
+		for key := range keySet {
+			names = append(names, key)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		columns = append(columns, csvColumn{Name: name})
+	}
+	return columns
+}
+
+// csvStructFieldByIndex acts as reflect.Value.FieldByIndex, but it returns invalid value
+// instead of panic if there is any nil embedded pointer on the path.
+func csvStructFieldByIndex(reflectValue reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && reflectValue.Kind() == reflect.Ptr {
+			if reflectValue.IsNil() {
+				return reflect.Value{}
+			}
+			reflectValue = reflectValue.Elem()
+		}
+		reflectValue = reflectValue.Field(x)
+	}
+	return reflectValue
+}
+
+// formatCSVValue converts the cell value to string according to `option`.
+func formatCSVValue(reflectValue reflect.Value, option CSVOption) string {
+	for reflectValue.Kind() == reflect.Ptr || reflectValue.Kind() == reflect.Interface {
+		if reflectValue.IsNil() {
+			return ""
+		}
+		reflectValue = reflectValue.Elem()
+	}
+	if !reflectValue.IsValid() || !reflectValue.CanInterface() {
+		return ""
+	}
+	switch value := reflectValue.Interface().(type) {
+	case time.Time:
+		if option.TimeFormat != "" {
+			if value.IsZero() {
+				return ""
+			}
+			return value.Format(option.TimeFormat)
+		}
+	case float32:
+		if option.FloatFormat != 0 {
+			return strconv.FormatFloat(float64(value), option.FloatFormat, option.FloatPrecision, 32)
+		}
+	case float64:
+		if option.FloatFormat != 0 {
+			return strconv.FormatFloat(value, option.FloatFormat, option.FloatPrecision, 64)
+		}
+	}
+	return String(reflectValue.Interface())
+}
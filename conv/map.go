@@ -11,6 +11,7 @@ import (
 	"github.com/gocarp/helpers/empty"
 	"github.com/gocarp/helpers/json"
 	"github.com/gocarp/helpers/utils"
+	"github.com/gocarp/utils/redact"
 	"github.com/gocarp/utils/tag"
 )
 
@@ -33,6 +34,15 @@ type MapOption struct {
 
 	// Tags specifies the converted map key name by struct tag name.
 	Tags []string
+
+	// Redact replaces the secret values with redact.Mask, see package redact.
+	// Note that only the converted levels are redacted, which are all levels if Deep is true.
+	Redact bool
+
+	// ContentFormat specifies decoding the string/[]byte value as content of the format, see DecodeContent.
+	// If it is ContentFormatAuto, the JSON content is decoded, and the detected YAML/TOML/INI content
+	// is decoded only if it is enabled by SetContentDecoding.
+	ContentFormat ContentFormat
 }
 
 // Map converts any variable `value` to map[string]interface{}. If the parameter `value` is not a
@@ -57,7 +67,8 @@ func MapDeep(value interface{}, tags ...string) map[string]interface{} {
 }
 
 // doMapConvert implements the map converting.
-// It automatically checks and converts json string to map if `value` is string/[]byte.
+// It automatically checks and converts json string to map if `value` is string/[]byte,
+// and the YAML/TOML/INI content if specified by MapOption.ContentFormat or SetContentDecoding.
 //
 // TODO completely implement the recursive converting for all types, especially the map.
 func doMapConvert(value interface{}, recursive recursiveType, mustMapReturn bool, option ...MapOption) map[string]interface{} {
@@ -84,12 +95,18 @@ func doMapConvert(value interface{}, recursive recursiveType, mustMapReturn bool
 	default:
 		newTags = append(usedOption.Tags, tag.StructTagPriority...)
 	}
+	if usedOption.Redact && reflect.Indirect(reflect.ValueOf(value)).Kind() == reflect.Map {
+		// The common map types are redacted by reflection, which checks each key.
+		value = reflect.ValueOf(value)
+	}
 	// Assert the common combination of types, and finally it uses reflection.
 	dataMap := make(map[string]interface{})
 	switch r := value.(type) {
 	case string:
-		// If it is a JSON string, automatically unmarshal it!
-		if len(r) > 0 && r[0] == '{' && r[len(r)-1] == '}' {
+		if m, ok := doDecodeContentCheck(r, usedOption.ContentFormat); ok {
+			// If it is a YAML/TOML/INI string, decode it!
+			dataMap = m
+		} else if len(r) > 0 && r[0] == '{' && r[len(r)-1] == '}' {
 			if err := json.UnmarshalUseNumber([]byte(r), &dataMap); err != nil {
 				return nil
 			}
@@ -97,8 +114,10 @@ func doMapConvert(value interface{}, recursive recursiveType, mustMapReturn bool
 			return nil
 		}
 	case []byte:
-		// If it is a JSON string, automatically unmarshal it!
-		if len(r) > 0 && r[0] == '{' && r[len(r)-1] == '}' {
+		if m, ok := doDecodeContentCheck(r, usedOption.ContentFormat); ok {
+			// If it is a YAML/TOML/INI content, decode it!
+			dataMap = m
+		} else if len(r) > 0 && r[0] == '{' && r[len(r)-1] == '}' {
 			if err := json.UnmarshalUseNumber(r, &dataMap); err != nil {
 				return nil
 			}
@@ -258,6 +277,20 @@ func doMapConvert(value interface{}, recursive recursiveType, mustMapReturn bool
 	return dataMap
 }
 
+// isRedactedMapEntry checks whether the map value `value` of `key` is secret.
+func isRedactedMapEntry(key, value reflect.Value) bool {
+	if key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	if key.Kind() == reflect.String && redact.IsName(key.String()) {
+		return true
+	}
+	if value.Kind() == reflect.Interface && !value.IsNil() {
+		value = value.Elem()
+	}
+	return value.IsValid() && redact.IsType(value.Type())
+}
+
 func getUsedMapOption(option ...MapOption) MapOption {
 	var usedOption MapOption
 	if len(option) > 0 {
@@ -317,6 +350,10 @@ func doMapConvertForMapOrStructValue(in doMapConvertForMapOrStructValueInput) in
 			default:
 				mapValue = mapKeyValue.Interface()
 			}
+			if in.Option.Redact && isRedactedMapEntry(mapIter.Key(), mapKeyValue) {
+				dataMap[String(mapIter.Key().Interface())] = redact.Mask
+				continue
+			}
 			dataMap[String(mapIter.Key().Interface())] = doMapConvertForMapOrStructValue(
 				doMapConvertForMapOrStructValueInput{
 					IsRoot:          false,
@@ -400,6 +437,10 @@ func doMapConvertForMapOrStructValue(in doMapConvertForMapOrStructValueInput) in
 					mapKey = fieldName
 				}
 			}
+			if in.Option.Redact && redact.IsField(rtField) {
+				dataMap[mapKey] = redact.Mask
+				continue
+			}
 			if in.RecursiveOption || rtField.Anonymous {
 				// Do map converting recursively.
 				var (
@@ -488,6 +529,10 @@ func doMapConvertForMapOrStructValue(in doMapConvertForMapOrStructValueInput) in
 						nestedMap = make(map[string]interface{})
 					)
 					for mapIter.Next() {
+						if in.Option.Redact && isRedactedMapEntry(mapIter.Key(), mapIter.Value()) {
+							nestedMap[String(mapIter.Key().Interface())] = redact.Mask
+							continue
+						}
 						nestedMap[String(mapIter.Key().Interface())] = doMapConvertForMapOrStructValue(
 							doMapConvertForMapOrStructValueInput{
 								IsRoot:          false,
@@ -29,6 +29,9 @@ import (
 //     It will automatically convert the first letter of the key to uppercase
 //     in mapping procedure to do the matching.
 //     It ignores the map key, if it does not match.
+//  5. If `params` is string/[]byte of JSON content, it is decoded automatically, and so is the
+//     YAML/TOML/INI content if it is enabled by SetContentDecoding, or else it should be
+//     converted using StructFromContent.
 func Struct(params interface{}, pointer interface{}, paramKeyToAttrMap ...map[string]string) (err error) {
 	return Scan(params, pointer, paramKeyToAttrMap...)
 }
@@ -61,6 +64,11 @@ func doStruct(
 		return nil
 	}
 
+	// YAML/TOML/INI content converting, the content is decoded to map for the following converting.
+	if m, ok := doDecodeContentCheck(params, ContentFormatAuto); ok {
+		params = m
+	}
+
 	defer func() {
 		// Catch the panic, especially the reflection operation panics.
 		if exception := recover(); exception != nil {
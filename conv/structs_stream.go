@@ -0,0 +1,100 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+)
+
+// StructsFromReader reads JSON array from `reader` and converts its elements into the slice that
+// `pointer` points to, element by element, like Structs.
+//
+// Different from Structs, it does not read the whole JSON content into memory before converting,
+// and only one decoded element is kept in memory besides the result slice. The returned error
+// contains the index of the element that fails.
+// The parameter `pointer` should be type of pointer to slice, eg: *[]struct/*[]*struct.
+func StructsFromReader(reader io.Reader, pointer interface{}, paramKeyToAttrMap ...map[string]string) error {
+	var pointerRv = reflect.ValueOf(pointer)
+	if pointerRv.Kind() != reflect.Ptr || pointerRv.IsNil() || pointerRv.Elem().Kind() != reflect.Slice {
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid parameter type "%T", should be type of pointer to slice`,
+			pointer,
+		)
+	}
+	var (
+		sliceRv  = pointerRv.Elem()
+		elemType = sliceRv.Type().Elem()
+	)
+	return rangeJSONArray(reader, func(index int, item interface{}) error {
+		var elemPtr = reflect.New(elemType)
+		if err := Scan(item, elemPtr.Interface(), paramKeyToAttrMap...); err != nil {
+			return errors.Wrapf(err, `convert element at index %d failed`, index)
+		}
+		sliceRv.Set(reflect.Append(sliceRv, elemPtr.Elem()))
+		return nil
+	})
+}
+
+// StructsRange reads JSON array from `reader` and converts its elements into type T one by one,
+// like Struct, then calls `fn` with each element, so that large JSON array can be processed with
+// bounded memory.
+//
+// The parameter `err` of `fn` is the converting error of the element, and the `item` is the
+// zero value of T if `err` is not nil. It stops reading if `fn` returns error, which is returned
+// by StructsRange, so that `fn` can either skip the failed element or stop processing.
+func StructsRange[T any](reader io.Reader, fn func(index int, item T, err error) error) error {
+	return rangeJSONArray(reader, func(index int, item interface{}) error {
+		var value T
+		if err := Scan(item, &value); err != nil {
+			var zero T
+			return fn(index, zero, errors.Wrapf(err, `convert element at index %d failed`, index))
+		}
+		return fn(index, value, nil)
+	})
+}
+
+// rangeJSONArray reads JSON array from `reader` and calls `fn` with each decoded element.
+// The numbers are decoded as json.Number to keep their precision.
+func rangeJSONArray(reader io.Reader, fn func(index int, item interface{}) error) error {
+	var decoder = json.NewDecoder(reader)
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return errors.Wrap(err, `read JSON array failed`)
+	}
+	if token == nil {
+		// JSON null is treated as empty array.
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid JSON content, should be JSON array, but got "%v"`,
+			token,
+		)
+	}
+	for index := 0; decoder.More(); index++ {
+		var item interface{}
+		if err = decoder.Decode(&item); err != nil {
+			return errors.Wrapf(err, `read element at index %d failed`, index)
+		}
+		if err = fn(index, item); err != nil {
+			return err
+		}
+	}
+	if _, err = decoder.Token(); err != nil {
+		return errors.Wrap(err, `read JSON array end failed`)
+	}
+	return nil
+}
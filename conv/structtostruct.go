@@ -0,0 +1,498 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/helpers/utils"
+	"github.com/gocarp/utils/tag"
+)
+
+// StructToStructOption specifies the option for function StructToStruct.
+type StructToStructOption struct {
+	// Mapping specifies the explicit attribute mapping, which overrides the automatic matching.
+	// The key is the destination attribute name, and the value is the source attribute path,
+	// which can be nested attribute path like "User.Name".
+	// It applies only to the top-level struct or the items of top-level slice, but not the nested structs.
+	Mapping map[string]string
+
+	// Ignore specifies the destination attribute names or tag names that are not mapped.
+	// It applies only to the top-level struct or the items of top-level slice, but not the nested structs.
+	Ignore []string
+
+	// Tags specifies the priority tags for attribute matching, which are searched before StructTagPriority.
+	Tags []string
+}
+
+// structToStructField is the mapping of a single destination attribute.
+type structToStructField struct {
+	DstIndex []int // Index sequence of destination attribute, see reflect.Value.FieldByIndex.
+	SrcIndex []int // Index sequence of source attribute, the pointer attributes on the path are dereferenced.
+}
+
+// structToStructCacheKey is the cache key for mapping plans of type pair with option.
+type structToStructCacheKey struct {
+	SrcType   reflect.Type
+	DstType   reflect.Type
+	OptionKey string
+}
+
+// structToStructVisitKey is the identity of mapped source pointer for the destination type.
+type structToStructVisitKey struct {
+	SrcType    reflect.Type
+	SrcPointer uintptr
+	DstType    reflect.Type
+}
+
+// structToStructMapper maps the values of a single StructToStruct calling.
+type structToStructMapper struct {
+	// visited keeps the mapped destination pointers of source pointers, so that the shared and
+	// cyclic pointers in source, like back-pointers to parent, are mapped to the same destination.
+	visited map[structToStructVisitKey]reflect.Value
+	// mapping marks the source pointers that are being mapped to non-pointer destinations,
+	// which cannot be shared and are cyclic if the source pointers are met again.
+	mapping map[structToStructVisitKey]struct{}
+}
+
+// structToStructFieldInfo is the attribute information for mapping plan building.
+type structToStructFieldInfo struct {
+	Name    string
+	TagName string
+	Index   []int
+	Type    reflect.Type
+}
+
+// structToStructCache caches the mapping plans, the value is type of []structToStructField.
+var structToStructCache sync.Map
+
+// StructToStruct maps struct `src` to the struct that `dstPointer` points to directly, without
+// converting `src` to intermediate map, which keeps the attribute types.
+//
+// The parameter `src` can be type of struct/*struct, or slice of them if `dstPointer` points to slice.
+// The parameter `dstPointer` should be type of *struct/**struct/*[]struct/*[]*struct.
+//
+// The destination attributes are matched in order of:
+//  1. The explicit mapping in option;
+//  2. The source attribute of the same name;
+//  3. The source attribute of which the name or tag name equals the destination tag name;
+//  4. The flattened attribute of nested source struct, eg: "User.Name" for "UserName";
+//  5. The source attribute name matches case-insensitively, ignoring symbols '-'/'_'/'.'/' '.
+//
+// The attribute values are assigned directly if the types are the same, or else they are converted
+// using the registered converters and the converting functions of this package. The nested structs
+// are also mapped using StructToStruct. The mapping plan is cached for each pair of types.
+//
+// The source pointers that are shared or cyclic, like back-pointers to parent, are mapped to the
+// same destination pointer. It returns error if the cyclic source is mapped to non-pointer destination.
+func StructToStruct(src interface{}, dstPointer interface{}, option ...StructToStructOption) (err error) {
+	if src == nil {
+		return nil
+	}
+	var (
+		usedOption     StructToStructOption
+		srcReflectVal  reflect.Value
+		dstReflectVal  reflect.Value
+		dstPointerKind reflect.Kind
+	)
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	if v, ok := src.(reflect.Value); ok {
+		srcReflectVal = v
+	} else {
+		srcReflectVal = reflect.ValueOf(src)
+	}
+	if v, ok := dstPointer.(reflect.Value); ok {
+		dstReflectVal = v
+	} else {
+		dstReflectVal = reflect.ValueOf(dstPointer)
+	}
+	dstPointerKind = dstReflectVal.Kind()
+	if dstPointerKind != reflect.Ptr || dstReflectVal.IsNil() {
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			"destination pointer should be type of non-nil pointer, but got '%v'",
+			dstReflectVal.Type(),
+		)
+	}
+	defer func() {
+		// Catch the panic, especially the reflection operation panics.
+		if exception := recover(); exception != nil {
+			if v, ok := exception.(error); ok && errors.HasStack(v) {
+				err = v
+			} else {
+				err = errors.NewCodeSkipf(codes.CodeInternalPanic, 1, "%+v", exception)
+			}
+		}
+	}()
+	var mapper = &structToStructMapper{
+		visited: make(map[structToStructVisitKey]reflect.Value),
+		mapping: make(map[structToStructVisitKey]struct{}),
+	}
+	return mapper.assign(srcReflectVal, dstReflectVal.Elem(), usedOption)
+}
+
+// assign assigns `src` to settable `dst` using the struct mapping plans.
+func (m *structToStructMapper) assign(src, dst reflect.Value, option StructToStructOption) error {
+	for src.Kind() == reflect.Interface && !src.IsNil() {
+		src = src.Elem()
+	}
+	if !src.IsValid() {
+		return nil
+	}
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+	if ok, err := callCustomConverter(src, dst); ok || err != nil {
+		return err
+	}
+	if src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return nil
+		}
+		var key = structToStructVisitKey{
+			SrcType:    src.Type(),
+			SrcPointer: src.Pointer(),
+			DstType:    dst.Type(),
+		}
+		if dst.Kind() != reflect.Ptr {
+			if _, ok := m.mapping[key]; ok {
+				return errors.NewCodef(
+					codes.CodeInvalidParameter,
+					`cyclic source value of type "%s" cannot be mapped to non-pointer type "%s"`,
+					src.Type().String(), dst.Type().String(),
+				)
+			}
+			m.mapping[key] = struct{}{}
+			defer delete(m.mapping, key)
+			return m.assign(src.Elem(), dst, option)
+		}
+		if mapped, ok := m.visited[key]; ok {
+			dst.Set(mapped)
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		// The destination is recorded before mapping, for the cyclic source pointers.
+		m.visited[key] = dst
+		return m.assign(src.Elem(), dst.Elem(), option)
+	}
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return m.assign(src, dst.Elem(), option)
+	}
+	switch {
+	case src.Kind() == reflect.Struct && dst.Kind() == reflect.Struct &&
+		hasExportedStructField(src.Type()) && hasExportedStructField(dst.Type()):
+		return m.assignStruct(src, dst, option)
+
+	case (src.Kind() == reflect.Slice || src.Kind() == reflect.Array) &&
+		(dst.Kind() == reflect.Slice || dst.Kind() == reflect.Array):
+		if src.Kind() == reflect.Slice && src.IsNil() {
+			return nil
+		}
+		var (
+			length   = src.Len()
+			dstItems = reflect.New(dst.Type()).Elem()
+		)
+		if dst.Kind() == reflect.Slice {
+			dstItems = reflect.MakeSlice(dst.Type(), length, length)
+		} else if dst.Len() < length {
+			length = dst.Len()
+		}
+		for i := 0; i < length; i++ {
+			if err := m.assign(src.Index(i), dstItems.Index(i), option); err != nil {
+				return err
+			}
+		}
+		dst.Set(dstItems)
+		return nil
+	}
+	var srcInterface = src.Interface()
+	switch dst.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Interface:
+		return bindVarToReflectValue(dst, srcInterface, nil)
+	}
+	if ok, err := bindVarToReflectValueWithInterfaceCheck(dst, srcInterface); ok {
+		return err
+	}
+	doConvertWithReflectValueSet(dst, doConvertInput{
+		FromValue:  srcInterface,
+		ToTypeName: dst.Type().String(),
+		ReferValue: dst,
+	})
+	return nil
+}
+
+// assignStruct maps struct `src` to struct `dst` using the cached mapping plan.
+// The nested structs are mapped without the Mapping and Ignore of `option`,
+// which are for the top-level struct only.
+func (m *structToStructMapper) assignStruct(src, dst reflect.Value, option StructToStructOption) error {
+	fields, err := getStructToStructFields(src.Type(), dst.Type(), option)
+	if err != nil {
+		return err
+	}
+	var nestedOption = StructToStructOption{Tags: option.Tags}
+	for _, field := range fields {
+		srcField, ok := structFieldByIndexNoAlloc(src, field.SrcIndex)
+		if !ok {
+			continue
+		}
+		dstField, ok := structFieldByIndexAlloc(dst, field.DstIndex)
+		if !ok {
+			continue
+		}
+		if err = m.assign(srcField, dstField, nestedOption); err != nil {
+			return errors.Wrapf(err, `error mapping value to attribute "%s"`, dst.Type().FieldByIndex(field.DstIndex).Name)
+		}
+	}
+	return nil
+}
+
+// getStructToStructFields retrieves the mapping plan from cache, or builds and caches it.
+func getStructToStructFields(
+	srcType, dstType reflect.Type, option StructToStructOption,
+) ([]structToStructField, error) {
+	var cacheKey = structToStructCacheKey{
+		SrcType:   srcType,
+		DstType:   dstType,
+		OptionKey: getStructToStructOptionKey(option),
+	}
+	if v, ok := structToStructCache.Load(cacheKey); ok {
+		return v.([]structToStructField), nil
+	}
+	fields, err := buildStructToStructFields(srcType, dstType, option)
+	if err != nil {
+		return nil, err
+	}
+	structToStructCache.Store(cacheKey, fields)
+	return fields, nil
+}
+
+func getStructToStructOptionKey(option StructToStructOption) string {
+	var items = make([]string, 0, len(option.Mapping))
+	for k, v := range option.Mapping {
+		items = append(items, k+"="+v)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",") + "|" + strings.Join(option.Ignore, ",") + "|" + strings.Join(option.Tags, ",")
+}
+
+func buildStructToStructFields(
+	srcType, dstType reflect.Type, option StructToStructOption,
+) ([]structToStructField, error) {
+	var (
+		priorityTags = append(append([]string{}, option.Tags...), tag.StructTagPriority...)
+		srcInfos     = getStructToStructFieldInfos(srcType, priorityTags, nil)
+		dstInfos     = getStructToStructFieldInfos(dstType, priorityTags, nil)
+		ignored      = make(map[string]struct{}, len(option.Ignore))
+		fields       = make([]structToStructField, 0, len(dstInfos))
+	)
+	for _, name := range option.Ignore {
+		ignored[name] = struct{}{}
+	}
+	for _, dstInfo := range dstInfos {
+		if _, ok := ignored[dstInfo.Name]; ok {
+			continue
+		}
+		if _, ok := ignored[dstInfo.TagName]; ok && dstInfo.TagName != "" {
+			continue
+		}
+		var (
+			srcIndex []int
+			srcPath  string
+			ok       bool
+		)
+		if srcPath, ok = option.Mapping[dstInfo.Name]; !ok && dstInfo.TagName != "" {
+			srcPath, ok = option.Mapping[dstInfo.TagName]
+		}
+		if ok {
+			if srcIndex, ok = searchStructToStructPath(srcType, strings.Split(srcPath, "."), priorityTags); !ok {
+				return nil, errors.NewCodef(
+					codes.CodeInvalidParameter,
+					`source attribute "%s" mapped to "%s" not found in type "%s"`,
+					srcPath, dstInfo.Name, srcType.String(),
+				)
+			}
+		} else if srcIndex, ok = matchStructToStructField(dstInfo, srcType, srcInfos, priorityTags); !ok {
+			continue
+		}
+		fields = append(fields, structToStructField{
+			DstIndex: dstInfo.Index,
+			SrcIndex: srcIndex,
+		})
+	}
+	return fields, nil
+}
+
+// matchStructToStructField searches the source attribute for destination attribute `dstInfo`.
+func matchStructToStructField(
+	dstInfo structToStructFieldInfo,
+	srcType reflect.Type,
+	srcInfos []structToStructFieldInfo,
+	priorityTags []string,
+) ([]int, bool) {
+	for _, srcInfo := range srcInfos {
+		if srcInfo.Name == dstInfo.Name {
+			return srcInfo.Index, true
+		}
+	}
+	if dstInfo.TagName != "" {
+		for _, srcInfo := range srcInfos {
+			if srcInfo.TagName == dstInfo.TagName || srcInfo.Name == dstInfo.TagName {
+				return srcInfo.Index, true
+			}
+		}
+	}
+	if index, ok := searchStructToStructFlattened(srcType, dstInfo.Name, priorityTags); ok {
+		return index, true
+	}
+	for _, srcInfo := range srcInfos {
+		if utils.EqualFoldWithoutChars(srcInfo.Name, dstInfo.Name) {
+			return srcInfo.Index, true
+		}
+	}
+	return nil, false
+}
+
+// searchStructToStructFlattened searches the nested source attribute for flattened `name`,
+// eg: it returns the index sequence of "User.Name" for "UserName".
+func searchStructToStructFlattened(srcType reflect.Type, name string, priorityTags []string) ([]int, bool) {
+	for _, srcInfo := range getStructToStructFieldInfos(srcType, priorityTags, nil) {
+		if srcInfo.Name == name {
+			return srcInfo.Index, true
+		}
+		var nestedType = srcInfo.Type
+		for nestedType.Kind() == reflect.Ptr {
+			nestedType = nestedType.Elem()
+		}
+		if nestedType.Kind() != reflect.Struct || nestedType == srcType ||
+			len(name) <= len(srcInfo.Name) || !strings.HasPrefix(name, srcInfo.Name) {
+			continue
+		}
+		if index, ok := searchStructToStructFlattened(nestedType, name[len(srcInfo.Name):], priorityTags); ok {
+			return append(append([]int{}, srcInfo.Index...), index...), true
+		}
+	}
+	return nil, false
+}
+
+// searchStructToStructPath searches the source attribute by attribute path `names`.
+func searchStructToStructPath(srcType reflect.Type, names []string, priorityTags []string) ([]int, bool) {
+	var index = make([]int, 0)
+	for _, name := range names {
+		for srcType.Kind() == reflect.Ptr {
+			srcType = srcType.Elem()
+		}
+		if srcType.Kind() != reflect.Struct {
+			return nil, false
+		}
+		var found = false
+		for _, srcInfo := range getStructToStructFieldInfos(srcType, priorityTags, nil) {
+			if srcInfo.Name == name || srcInfo.TagName == name {
+				index = append(index, srcInfo.Index...)
+				srcType = srcInfo.Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return index, true
+}
+
+// getStructToStructFieldInfos retrieves the exported attributes of struct type `structType`,
+// the attributes of embedded struct without tag are retrieved as attributes of `structType`.
+func getStructToStructFieldInfos(
+	structType reflect.Type, priorityTags []string, parentIndex []int,
+) []structToStructFieldInfo {
+	var infos = make([]structToStructFieldInfo, 0)
+	for i := 0; i < structType.NumField(); i++ {
+		var (
+			field   = structType.Field(i)
+			index   = append(append([]int{}, parentIndex...), i)
+			tagName = getTagNameFromField(field, priorityTags)
+		)
+		if !field.IsExported() || tagName == "-" {
+			continue
+		}
+		if field.Anonymous && tagName == "" {
+			var embeddedType = field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				infos = append(infos, getStructToStructFieldInfos(embeddedType, priorityTags, index)...)
+				continue
+			}
+		}
+		infos = append(infos, structToStructFieldInfo{
+			Name:    field.Name,
+			TagName: tagName,
+			Index:   index,
+			Type:    field.Type,
+		})
+	}
+	return infos
+}
+
+// structFieldByIndexNoAlloc acts as reflect.Value.FieldByIndex, but it returns false
+// instead of panic if there is any nil pointer on the path.
+func structFieldByIndexNoAlloc(reflectValue reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			for reflectValue.Kind() == reflect.Ptr {
+				if reflectValue.IsNil() {
+					return reflect.Value{}, false
+				}
+				reflectValue = reflectValue.Elem()
+			}
+		}
+		reflectValue = reflectValue.Field(x)
+	}
+	return reflectValue, true
+}
+
+// structFieldByIndexAlloc acts as reflect.Value.FieldByIndex, but it allocates the nil pointers
+// of embedded structs on the path. It returns false if any nil pointer on the path cannot be set,
+// like the pointer to unexported embedded struct.
+func structFieldByIndexAlloc(reflectValue reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			for reflectValue.Kind() == reflect.Ptr {
+				if reflectValue.IsNil() {
+					if !reflectValue.CanSet() {
+						return reflect.Value{}, false
+					}
+					reflectValue.Set(reflect.New(reflectValue.Type().Elem()))
+				}
+				reflectValue = reflectValue.Elem()
+			}
+		}
+		reflectValue = reflectValue.Field(x)
+	}
+	return reflectValue, true
+}
+
+func hasExportedStructField(structType reflect.Type) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		if structType.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
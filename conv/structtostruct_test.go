@@ -0,0 +1,115 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package conv
+
+import (
+	"testing"
+)
+
+type structToStructNode struct {
+	Name     string
+	Parent   *structToStructNode
+	Children []*structToStructNode
+}
+
+type structToStructNodeDTO struct {
+	Name     string
+	Parent   *structToStructNodeDTO
+	Children []*structToStructNodeDTO
+}
+
+func TestStructToStruct_Cyclic(t *testing.T) {
+	var (
+		root  = &structToStructNode{Name: "root"}
+		child = &structToStructNode{Name: "child", Parent: root}
+		dto   structToStructNodeDTO
+	)
+	root.Children = []*structToStructNode{child, child}
+	if err := StructToStruct(root, &dto); err != nil {
+		t.Fatal(err)
+	}
+	if dto.Name != "root" || len(dto.Children) != 2 || dto.Children[0].Name != "child" {
+		t.Fatalf("unexpected mapped value: %+v", dto)
+	}
+	// The shared and back pointers are mapped to the same destination pointers.
+	if dto.Children[0] != dto.Children[1] {
+		t.Fatal("expect shared children mapped to the same pointer")
+	}
+	if dto.Children[0].Parent == nil || dto.Children[0].Parent.Children[0] != dto.Children[0] {
+		t.Fatal("expect parent of child mapped to the root")
+	}
+}
+
+func TestStructToStruct_CyclicToValue(t *testing.T) {
+	type Node struct {
+		Name string
+		Next []*Node
+	}
+	type NodeDTO struct {
+		Name string
+		Next []NodeDTO
+	}
+	type Wrapper struct {
+		Node *Node
+	}
+	type WrapperDTO struct {
+		Node NodeDTO
+	}
+	var node = &Node{Name: "a"}
+	node.Next = []*Node{node}
+	var dto WrapperDTO
+	if err := StructToStruct(Wrapper{Node: node}, &dto); err == nil {
+		t.Fatal("expect error for cyclic source mapped to non-pointer destination")
+	}
+}
+
+func TestStructToStruct_NestedOption(t *testing.T) {
+	type User struct {
+		Name string
+	}
+	type Address struct {
+		Name string
+		City string
+	}
+	type Order struct {
+		User User
+		Addr Address
+		Note string
+	}
+	type AddressDTO struct {
+		Name string
+		City string
+		Note string
+	}
+	type OrderDTO struct {
+		Name string
+		Addr AddressDTO
+		Note string
+	}
+	var (
+		order = Order{
+			User: User{Name: "user"},
+			Addr: Address{Name: "home", City: "x"},
+			Note: "note",
+		}
+		dto    OrderDTO
+		option = StructToStructOption{
+			Mapping: map[string]string{"Name": "User.Name"},
+			Ignore:  []string{"City"},
+		}
+	)
+	if err := StructToStruct(order, &dto, option); err != nil {
+		t.Fatal(err)
+	}
+	// The Mapping and Ignore apply to the top-level struct only.
+	var expect = OrderDTO{
+		Name: "user",
+		Addr: AddressDTO{Name: "home", City: "x"},
+		Note: "note",
+	}
+	if dto != expect {
+		t.Fatalf("\nexpect: %+v\nactual: %+v", expect, dto)
+	}
+}
@@ -4,13 +4,286 @@
 
 package utils
 
-import "github.com/gocarp/helpers/deepcopy"
+import (
+	"reflect"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/helpers/deepcopy"
+	"github.com/gocarp/utils/tag"
+)
+
+const (
+	copyTagSkip    = "-"
+	copyTagShallow = "shallow"
+)
+
+// CopyOption specifies the option for function CopyWithOption.
+type CopyOption struct {
+	// Unexported specifies copying the unexported attributes of structs, which are ignored in default.
+	Unexported bool
+
+	// ShallowTypes specifies the types that are copied shallowly, which means they are assigned directly.
+	// Eg: reflect.TypeOf((*sql.DB)(nil)).
+	ShallowTypes []reflect.Type
+}
+
+var (
+	// customCopiers for internal copier storing, key is the type and value is the copier function.
+	customCopiers = map[reflect.Type]reflect.Value{
+		reflect.TypeOf(time.Time{}): reflect.ValueOf(func(t time.Time) time.Time { return t }),
+	}
+	customCopiersMu sync.RWMutex
+
+	// copyResetTypes are the types that should not be copied, their zero values are used instead.
+	copyResetTypes = map[reflect.Type]struct{}{
+		reflect.TypeOf(sync.Mutex{}):   {},
+		reflect.TypeOf(sync.RWMutex{}): {},
+		reflect.TypeOf(sync.Once{}):    {},
+	}
+)
 
 // Copy returns a deep copy of v.
 //
 // Copy is unable to copy unexported fields in a struct (lowercase field names).
 // Unexported fields can't be reflected by the Go runtime, and therefore
-// they can't perform any data copies.
+// they can't perform any data copies. Use CopyWithOption for unexported fields copying.
 func Copy(src interface{}) (dst interface{}) {
 	return deepcopy.Copy(src)
 }
+
+// CopyOf returns a deep copy of `src` in the same type, which does not need type assertion.
+// Also see CopyWithOption.
+func CopyOf[T any](src T, option ...CopyOption) T {
+	var dst T
+	copied := CopyWithOption(&src, option...).(*T)
+	if copied != nil {
+		dst = *copied
+	}
+	return dst
+}
+
+// CopyWithOption returns a deep copy of `src` according to `option`.
+//
+// Different from Copy, it keeps the identity of shared pointers, maps and slices, which means if
+// some pointers point to the same value in `src`, the copied pointers also point to the same copied
+// value, so it supports cyclic values. The slices starting at the same element of a backing array,
+// like `s` and `s[:2]`, also share the copied backing array.
+//
+// The struct attribute with tag `copy:"-"` is not copied and has its zero value, and the attribute
+// with tag `copy:"shallow"` is assigned directly. The types registered by RegisterCopier are copied
+// using the registered functions, and the types implementing `DeepCopy() interface{}` are copied by
+// calling it. The time.Time values are copied by value, and sync.Mutex/sync.RWMutex/sync.Once are reset.
+func CopyWithOption(src interface{}, option ...CopyOption) interface{} {
+	if src == nil {
+		return nil
+	}
+	c := &deepCopier{
+		shallowTypes: make(map[reflect.Type]struct{}),
+		copiers:      make(map[reflect.Type]reflect.Value),
+		visited:      make(map[copyVisitKey]reflect.Value),
+		slices:       make(map[copyVisitKey]*copiedSlice),
+	}
+	if len(option) > 0 {
+		c.option = option[0]
+	}
+	for _, shallowType := range c.option.ShallowTypes {
+		c.shallowTypes[shallowType] = struct{}{}
+	}
+	// The copiers are copied, as the lock should not be held when calling them, which might also
+	// call RegisterCopier.
+	customCopiersMu.RLock()
+	for copierType, copier := range customCopiers {
+		c.copiers[copierType] = copier
+	}
+	customCopiersMu.RUnlock()
+	return c.copy(reflect.ValueOf(src)).Interface()
+}
+
+// RegisterCopier registers custom copy function for type.
+// The parameter `fn` must be defined as pattern `func(T) T`, which returns the copy of given value.
+// It overwrites the copy function of the same type if it is already registered.
+func RegisterCopier(fn interface{}) error {
+	var fnReflectType = reflect.TypeOf(fn)
+	if fnReflectType == nil || fnReflectType.Kind() != reflect.Func ||
+		fnReflectType.NumIn() != 1 || fnReflectType.NumOut() != 1 ||
+		fnReflectType.In(0) != fnReflectType.Out(0) {
+		var typeName = "nil"
+		if fnReflectType != nil {
+			typeName = fnReflectType.String()
+		}
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			"parameter must be type of copier function and defined as pattern `func(T) T`, but defined as `%s`",
+			typeName,
+		)
+	}
+	customCopiersMu.Lock()
+	defer customCopiersMu.Unlock()
+	customCopiers[fnReflectType.In(0)] = reflect.ValueOf(fn)
+	return nil
+}
+
+// copyVisitKey is the identity of reference values for copied values tracking.
+// It is the type and pointer for pointers and maps, or the element type and pointer of the first
+// element for slices, as the slices of different lengths can share the same backing array.
+type copyVisitKey struct {
+	Type    reflect.Type
+	Pointer uintptr
+}
+
+// copiedSlice is the copied backing array of slices, of which the first Length elements are copied.
+type copiedSlice struct {
+	Backing reflect.Value
+	Length  int
+}
+
+type deepCopier struct {
+	option       CopyOption
+	shallowTypes map[reflect.Type]struct{}
+	copiers      map[reflect.Type]reflect.Value // Snapshot of customCopiers.
+	visited      map[copyVisitKey]reflect.Value
+	slices       map[copyVisitKey]*copiedSlice
+}
+
+// copy returns the deep copy of `src` in the same type.
+// Note that the returned value might be `src` itself for the values that need no copying.
+func (c *deepCopier) copy(src reflect.Value) reflect.Value {
+	if !src.IsValid() {
+		return src
+	}
+	var srcType = src.Type()
+	if _, ok := c.shallowTypes[srcType]; ok {
+		return src
+	}
+	if _, ok := copyResetTypes[srcType]; ok {
+		return reflect.Zero(srcType)
+	}
+	if fn, ok := c.copiers[srcType]; ok {
+		return fn.Call([]reflect.Value{src})[0]
+	}
+	if src.CanInterface() && (src.Kind() != reflect.Ptr || !src.IsNil()) {
+		if v, ok := src.Interface().(deepcopy.Interface); ok {
+			if copied := reflect.ValueOf(v.DeepCopy()); copied.IsValid() && copied.Type().AssignableTo(srcType) {
+				return copied
+			}
+		}
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return reflect.Zero(srcType)
+		}
+		var key = copyVisitKey{Type: srcType, Pointer: src.Pointer()}
+		if dst, ok := c.visited[key]; ok {
+			return dst
+		}
+		dst := reflect.New(srcType.Elem())
+		c.visited[key] = dst
+		dst.Elem().Set(c.copy(src.Elem()))
+		return dst
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return reflect.Zero(srcType)
+		}
+		dst := reflect.New(srcType).Elem()
+		dst.Set(c.copy(src.Elem()))
+		return dst
+
+	case reflect.Map:
+		if src.IsNil() {
+			return reflect.Zero(srcType)
+		}
+		var key = copyVisitKey{Type: srcType, Pointer: src.Pointer()}
+		if dst, ok := c.visited[key]; ok {
+			return dst
+		}
+		dst := reflect.MakeMapWithSize(srcType, src.Len())
+		c.visited[key] = dst
+		var iter = src.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(c.copy(iter.Key()), c.copy(iter.Value()))
+		}
+		return dst
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return reflect.Zero(srcType)
+		}
+		var (
+			key        = copyVisitKey{Type: srcType.Elem(), Pointer: src.Pointer()}
+			copied, ok = c.slices[key]
+		)
+		if !ok || copied.Backing.Cap() < src.Cap() {
+			// The backing array cannot grow, so the slice of greater capacity has its own copy.
+			copied = &copiedSlice{Backing: reflect.MakeSlice(srcType, src.Cap(), src.Cap())}
+			c.slices[key] = copied
+		}
+		// The Length is increased before copying the element, as the element might be the slice itself.
+		for copied.Length < src.Len() {
+			var i = copied.Length
+			copied.Length++
+			copied.Backing.Index(i).Set(c.copy(src.Index(i)))
+		}
+		var dst = copied.Backing.Slice3(0, src.Len(), src.Cap())
+		if dst.Type() != srcType {
+			dst = dst.Convert(srcType)
+		}
+		return dst
+
+	case reflect.Array:
+		dst := reflect.New(srcType).Elem()
+		for i := 0; i < src.Len(); i++ {
+			dst.Index(i).Set(c.copy(src.Index(i)))
+		}
+		return dst
+
+	case reflect.Struct:
+		return c.copyStruct(src)
+
+	default:
+		return src
+	}
+}
+
+func (c *deepCopier) copyStruct(src reflect.Value) reflect.Value {
+	var (
+		srcType = src.Type()
+		dst     = reflect.New(srcType).Elem()
+	)
+	if c.option.Unexported && !src.CanAddr() {
+		// The unexported attributes can only be accessed through address.
+		addressable := reflect.New(srcType).Elem()
+		addressable.Set(src)
+		src = addressable
+	}
+	for i := 0; i < src.NumField(); i++ {
+		var (
+			fieldType  = srcType.Field(i)
+			srcField   = src.Field(i)
+			dstField   = dst.Field(i)
+			copyTagStr = fieldType.Tag.Get(tag.Copy)
+		)
+		if copyTagStr == copyTagSkip {
+			continue
+		}
+		if !fieldType.IsExported() {
+			if !c.option.Unexported {
+				continue
+			}
+			srcField = reflect.NewAt(fieldType.Type, unsafe.Pointer(srcField.UnsafeAddr())).Elem()
+			dstField = reflect.NewAt(fieldType.Type, unsafe.Pointer(dstField.UnsafeAddr())).Elem()
+		}
+		if copyTagStr == copyTagShallow {
+			dstField.Set(srcField)
+			continue
+		}
+		dstField.Set(c.copy(srcField))
+	}
+	return dst
+}
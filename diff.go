@@ -0,0 +1,362 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/helpers/json"
+	"github.com/gocarp/utils/conv"
+)
+
+// ChangeOp is the operation type of Change.
+type ChangeOp string
+
+const (
+	ChangeOpAdd     ChangeOp = "add"     // The value is added, eg: map key or slice element.
+	ChangeOpRemove  ChangeOp = "remove"  // The value is removed.
+	ChangeOpReplace ChangeOp = "replace" // The value is replaced by another value.
+)
+
+// Change is a single difference between two values.
+type Change struct {
+	Op       ChangeOp    // Operation type.
+	Path     string      // Dot-separated path of the changed value, like that of GetPath.
+	Segments []string    // Segments of Path, which are used by Apply and JSONPatch as the map keys might contain ".".
+	From     interface{} // The old value, it is nil for ChangeOpAdd.
+	To       interface{} // The new value, it is nil for ChangeOpRemove.
+}
+
+// Changes is the list of Change, which is returned by function Diff.
+type Changes []Change
+
+// Diff compares `a` with `b` recursively and returns the changes that turn `a` into `b`.
+//
+// The maps are compared key by key, the structs are compared attribute by attribute of which the
+// name is its tag name in tag.StructTagPriority or attribute name, and the slices are compared
+// index by index. The other values are compared using reflect.DeepEqual.
+// The changes are returned in stable order, and the removed slice elements are listed from the
+// last index, so that the changes can be applied in order using Apply.
+func Diff(a, b interface{}) Changes {
+	d := &differ{
+		changes: make(Changes, 0),
+		visited: make(map[[2]uintptr]struct{}),
+	}
+	d.diff(toReflectValue(a), toReflectValue(b), make([]string, 0))
+	return d.changes
+}
+
+// Apply applies `changes` to `pointer` in order.
+// The parameter `pointer` should be type of pointer or map, as the value should be changeable.
+func Apply(pointer interface{}, changes Changes) error {
+	for _, change := range changes {
+		if err := applyChange(pointer, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONPatch returns the changes as RFC 6902 JSON Patch document.
+func (c Changes) JSONPatch() ([]byte, error) {
+	operations := make([]map[string]interface{}, 0, len(c))
+	for _, change := range c {
+		// It uses map instead of struct, as the zero value should also be kept for the "value" field.
+		operation := map[string]interface{}{
+			"op":   change.Op,
+			"path": toJSONPointer(change.pathSegments()),
+		}
+		if change.Op != ChangeOpRemove {
+			operation["value"] = change.To
+		}
+		operations = append(operations, operation)
+	}
+	return json.Marshal(operations)
+}
+
+// MergePatch compares `a` with `b` and returns RFC 7386 JSON Merge Patch document that turns
+// `a` into `b`. Both `a` and `b` are converted to JSON values before comparison, and as designed
+// by RFC 7386, the arrays are replaced as a whole if they are different.
+func MergePatch(a, b interface{}) ([]byte, error) {
+	var aValue, bValue interface{}
+	if err := toJSONValue(a, &aValue); err != nil {
+		return nil, err
+	}
+	if err := toJSONValue(b, &bValue); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doMergePatch(aValue, bValue))
+}
+
+// pathSegments returns the segments of the changed path, which are split from Path if Segments is
+// not set, eg: the Change is created manually.
+func (c Change) pathSegments() []string {
+	if c.Segments != nil {
+		return c.Segments
+	}
+	return splitPath(c.Path)
+}
+
+type differ struct {
+	changes Changes
+	visited map[[2]uintptr]struct{} // Visited pointer pairs, for cycle detection.
+}
+
+func (d *differ) add(op ChangeOp, path []string, from, to reflect.Value) {
+	change := Change{
+		Op:       op,
+		Path:     strings.Join(path, pathSeparator),
+		Segments: append(make([]string, 0, len(path)), path...),
+	}
+	if op != ChangeOpAdd {
+		change.From = reflectValueToInterface(from)
+	}
+	if op != ChangeOpRemove {
+		change.To = reflectValueToInterface(to)
+	}
+	d.changes = append(d.changes, change)
+}
+
+func (d *differ) diff(a, b reflect.Value, path []string) {
+	for a.Kind() == reflect.Interface && !a.IsNil() {
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface && !b.IsNil() {
+		b = b.Elem()
+	}
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		if a.IsValid() || b.IsValid() {
+			d.add(ChangeOpReplace, path, a, b)
+		}
+		return
+	}
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(ChangeOpReplace, path, a, b)
+			}
+			return
+		}
+		pair := [2]uintptr{a.Pointer(), b.Pointer()}
+		if _, ok := d.visited[pair]; ok {
+			return
+		}
+		d.visited[pair] = struct{}{}
+		d.diff(a.Elem(), b.Elem(), path)
+
+	case reflect.Map:
+		for _, key := range sortedMapKeys(a) {
+			var (
+				name   = conv.String(key.Interface())
+				bValue = b.MapIndex(key)
+			)
+			if !bValue.IsValid() {
+				d.add(ChangeOpRemove, appendPath(path, name), a.MapIndex(key), reflect.Value{})
+				continue
+			}
+			d.diff(a.MapIndex(key), bValue, appendPath(path, name))
+		}
+		for _, key := range sortedMapKeys(b) {
+			if !a.MapIndex(key).IsValid() {
+				d.add(ChangeOpAdd, appendPath(path, conv.String(key.Interface())), reflect.Value{}, b.MapIndex(key))
+			}
+		}
+
+	case reflect.Struct:
+		if !hasExportedField(a.Type()) {
+			d.diffDefault(a, b, path)
+			return
+		}
+		d.diffStruct(a, b, path)
+
+	case reflect.Slice, reflect.Array:
+		var (
+			aLength = a.Len()
+			bLength = b.Len()
+			common  = aLength
+		)
+		if bLength < common {
+			common = bLength
+		}
+		for i := 0; i < common; i++ {
+			d.diff(a.Index(i), b.Index(i), appendPath(path, strconv.Itoa(i)))
+		}
+		for i := common; i < bLength; i++ {
+			d.add(ChangeOpAdd, appendPath(path, strconv.Itoa(i)), reflect.Value{}, b.Index(i))
+		}
+		for i := aLength - 1; i >= common; i-- {
+			d.add(ChangeOpRemove, appendPath(path, strconv.Itoa(i)), a.Index(i), reflect.Value{})
+		}
+
+	default:
+		d.diffDefault(a, b, path)
+	}
+}
+
+// diffStruct compares structs `a` and `b` of the same type attribute by attribute, in which the
+// attributes of embedded structs are compared as promoted attributes. The embedded struct pointers
+// are replaced as a whole if only one of them is nil.
+func (d *differ) diffStruct(a, b reflect.Value, path []string) {
+	var reflectType = a.Type()
+	for i := 0; i < reflectType.NumField(); i++ {
+		var field = reflectType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		var (
+			aValue    = a.Field(i)
+			bValue    = b.Field(i)
+			fieldPath = appendPath(path, structFieldPathName(field))
+		)
+		if field.Anonymous && field.Tag == "" {
+			for aValue.Kind() == reflect.Ptr && !aValue.IsNil() && !bValue.IsNil() {
+				aValue, bValue = aValue.Elem(), bValue.Elem()
+			}
+			if aValue.Kind() == reflect.Struct {
+				d.diffStruct(aValue, bValue, path)
+				continue
+			}
+		}
+		d.diff(aValue, bValue, fieldPath)
+	}
+}
+
+func (d *differ) diffDefault(a, b reflect.Value, path []string) {
+	if a.CanInterface() && b.CanInterface() {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			d.add(ChangeOpReplace, path, a, b)
+		}
+	}
+}
+
+// applyChange applies single `change` to `pointer`.
+func applyChange(pointer interface{}, change Change) error {
+	var segments = change.pathSegments()
+	if len(segments) == 0 {
+		return setPathSegments(pointer, segments, change.To)
+	}
+	var (
+		lastIndex  = len(segments) - 1
+		last       = segments[lastIndex]
+		parentPath = segments[:lastIndex]
+	)
+	parent, err := getPathSegments(pointer, parentPath)
+	if err != nil {
+		return err
+	}
+	var parentValue = toReflectValue(parent)
+	for parentValue.Kind() == reflect.Ptr || parentValue.Kind() == reflect.Interface {
+		if parentValue.IsNil() {
+			break
+		}
+		parentValue = parentValue.Elem()
+	}
+	switch parentValue.Kind() {
+	case reflect.Slice:
+		index, err := strconv.Atoi(last)
+		if err != nil {
+			return newPathTypeMismatchError(segments, lastIndex, parentValue)
+		}
+		switch change.Op {
+		case ChangeOpAdd:
+			if index < 0 || index > parentValue.Len() {
+				return newPathNotFoundError(segments, lastIndex)
+			}
+			var item = reflect.New(parentValue.Type().Elem()).Elem()
+			if err = setReflectValue(item, change.To); err != nil {
+				return err
+			}
+			var newSlice = reflect.MakeSlice(parentValue.Type(), 0, parentValue.Len()+1)
+			newSlice = reflect.AppendSlice(newSlice, parentValue.Slice(0, index))
+			newSlice = reflect.Append(newSlice, item)
+			newSlice = reflect.AppendSlice(newSlice, parentValue.Slice(index, parentValue.Len()))
+			return setPathSegments(pointer, parentPath, newSlice.Interface())
+
+		case ChangeOpRemove:
+			if index < 0 || index >= parentValue.Len() {
+				return newPathNotFoundError(segments, lastIndex)
+			}
+			var newSlice = reflect.MakeSlice(parentValue.Type(), 0, parentValue.Len()-1)
+			newSlice = reflect.AppendSlice(newSlice, parentValue.Slice(0, index))
+			newSlice = reflect.AppendSlice(newSlice, parentValue.Slice(index+1, parentValue.Len()))
+			return setPathSegments(pointer, parentPath, newSlice.Interface())
+		}
+
+	case reflect.Map:
+		if change.Op == ChangeOpRemove {
+			key, err := pathMapKey(parentValue, segments, lastIndex)
+			if err != nil {
+				return err
+			}
+			if !parentValue.MapIndex(key).IsValid() {
+				return newPathNotFoundError(segments, lastIndex)
+			}
+			parentValue.SetMapIndex(key, reflect.Value{})
+			return nil
+		}
+	}
+	switch change.Op {
+	case ChangeOpAdd, ChangeOpReplace:
+		return setPathSegments(pointer, segments, change.To)
+	case ChangeOpRemove:
+		// The attribute of struct cannot be removed, it is reset to its zero value.
+		return setPathSegments(pointer, segments, nil)
+	default:
+		return errors.NewCodef(codes.CodeInvalidParameter, `invalid change operation "%s"`, change.Op)
+	}
+}
+
+// toJSONPointer converts path `segments` to RFC 6901 JSON Pointer.
+func toJSONPointer(segments []string) string {
+	var builder strings.Builder
+	for _, segment := range segments {
+		builder.WriteString("/")
+		builder.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(segment))
+	}
+	return builder.String()
+}
+
+// toJSONValue converts `value` to its JSON value representation into `pointer`.
+func toJSONValue(value interface{}, pointer *interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.UnmarshalUseNumber(content, pointer)
+}
+
+// doMergePatch implements the RFC 7386 JSON Merge Patch generating of JSON values.
+func doMergePatch(a, b interface{}) interface{} {
+	var (
+		aMap, aIsMap = a.(map[string]interface{})
+		bMap, bIsMap = b.(map[string]interface{})
+	)
+	if !aIsMap || !bIsMap {
+		return b
+	}
+	patch := make(map[string]interface{})
+	for k, aValue := range aMap {
+		bValue, ok := bMap[k]
+		if !ok {
+			patch[k] = nil
+			continue
+		}
+		if reflect.DeepEqual(aValue, bValue) {
+			continue
+		}
+		patch[k] = doMergePatch(aValue, bValue)
+	}
+	for k, bValue := range bMap {
+		if _, ok := aMap[k]; !ok {
+			patch[k] = bValue
+		}
+	}
+	return patch
+}
@@ -6,15 +6,21 @@ package utils
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/gocarp/go/structs"
 	"github.com/gocarp/helpers/reflection"
 	"github.com/gocarp/helpers/text/str"
+	"github.com/gocarp/utils/conv"
+	"github.com/gocarp/utils/redact"
 )
 
 // iString is used for type assert api for String().
@@ -34,11 +40,48 @@ type iMarshalJSON interface {
 
 // DumpOption specifies the behavior of function Export.
 type DumpOption struct {
-	WithType     bool // WithType specifies dumping content with type information.
-	ExportedOnly bool // Only dump Exported fields for structs.
+	WithType      bool       // WithType specifies dumping content with type information.
+	ExportedOnly  bool       // Only dump Exported fields for structs.
+	NoRedact      bool       // NoRedact disables redacting the secret values, see package redact.
+	Color         DumpColor  // Color specifies dumping content with ANSI colors, which is DumpColorAuto in default.
+	GoSyntax      bool       // GoSyntax specifies dumping content as valid Go source, eg: `&pkg.User{Name: "x"}`.
+	SortKeys      bool       // SortKeys specifies dumping map items in ascending order of their keys.
+	KeyComparator Comparator // KeyComparator specifies the custom comparator for map keys sorting if SortKeys is true.
+	Indent        string     // Indent specifies the indent string of nested items, which is four spaces in default.
+	NoKeyAlign    bool       // NoKeyAlign disables padding the map keys and struct field names to align their values.
+	CompactWidth  int        // CompactWidth specifies the maximum width of slices/maps/structs dumped in single line.
+
+	// The following limits are disabled if they are not greater than 0, and the elided content
+	// is dumped as marker "... (N more)".
+
+	MaxDepth        int // MaxDepth specifies the maximum nesting depth of slices/maps/structs, the deeper ones are elided.
+	MaxElements     int // MaxElements specifies the maximum count of dumped items for each slice/map.
+	MaxStringLength int // MaxStringLength specifies the maximum count of dumped characters for each string.
+	MaxBytes        int // MaxBytes specifies the total bytes budget, the rest items are elided once it is exceeded.
 }
 
+// DumpColor specifies whether dumping content with ANSI colors.
+type DumpColor int
+
+const (
+	DumpColorAuto   DumpColor = iota // Colors only if the writer is terminal and environment NO_COLOR is not set.
+	DumpColorAlways                  // Always colors.
+	DumpColorNever                   // Never colors.
+)
+
+const (
+	dumpColorReset  = "\x1b[0m"
+	dumpColorType   = "\x1b[36m" // Cyan.
+	dumpColorKey    = "\x1b[34m" // Blue.
+	dumpColorString = "\x1b[32m" // Green.
+	dumpColorNumber = "\x1b[33m" // Yellow.
+	dumpColorBool   = "\x1b[35m" // Magenta.
+	dumpColorNil    = "\x1b[90m" // Gray, also for elided markers.
+	dumpColorRef    = "\x1b[31m" // Red.
+)
+
 // Dump prints variables `values` to stdout with more manually readable.
+// The secret values are redacted with their type and length information kept, see package redact.
 func Dump(values ...interface{}) {
 	for _, value := range values {
 		DumpWithOption(value, DumpOption{
@@ -62,36 +105,97 @@ func DumpWithType(values ...interface{}) {
 // DumpWithOption returns variables `values` as a string with more manually readable.
 func DumpWithOption(value interface{}, option DumpOption) {
 	buffer := bytes.NewBuffer(nil)
-	DumpTo(buffer, value, DumpOption{
-		WithType:     option.WithType,
-		ExportedOnly: option.ExportedOnly,
+	doDumpTo(value, buffer, doDumpOption{
+		DumpOption: option,
+		Colored:    isDumpColored(os.Stdout, option.Color),
 	})
 	fmt.Println(buffer.String())
 }
 
-// DumpTo writes variables `values` as a string in to `writer` with more manually readable
-func DumpTo(writer io.Writer, value interface{}, option DumpOption) {
+// DumpString returns the dumped content of `value` as string, which is deterministic as the map
+// items are always dumped in ascending order of their keys, so it can be used for snapshot testing.
+// The optional parameter `option` specifies the other behaviors, see DumpOption.
+func DumpString(value interface{}, option ...DumpOption) string {
+	var usedOption DumpOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	usedOption.SortKeys = true
 	buffer := bytes.NewBuffer(nil)
-	doDump(value, "", buffer, doDumpOption{
-		WithType:     option.WithType,
-		ExportedOnly: option.ExportedOnly,
+	_ = DumpTo(buffer, value, usedOption)
+	return buffer.String()
+}
+
+// DumpTo writes variables `values` as a string in to `writer` with more manually readable.
+// It returns the error of writing to `writer`.
+func DumpTo(writer io.Writer, value interface{}, option DumpOption) error {
+	buffer := bytes.NewBuffer(nil)
+	doDumpTo(value, buffer, doDumpOption{
+		DumpOption: option,
+		Colored:    isDumpColored(writer, option.Color),
 	})
-	_, _ = writer.Write(buffer.Bytes())
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+// doDumpTo dumps `value` to `buffer` in the format that `option` specifies.
+func doDumpTo(value interface{}, buffer *bytes.Buffer, option doDumpOption) {
+	if option.GoSyntax {
+		doDumpGoSyntax(value, buffer, option)
+		return
+	}
+	var content = bytes.NewBuffer(nil)
+	option.Refs = newDumpRefs()
+	doDump(value, "", content, option)
+	option.Refs.WriteTo(buffer, content.Bytes(), option)
+}
+
+// isDumpColored checks whether dumping content to `writer` with ANSI colors.
+func isDumpColored(writer io.Writer, color DumpColor) bool {
+	switch color {
+	case DumpColorAlways:
+		return true
+	case DumpColorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps `s` with ANSI `color` if coloring is enabled.
+func (option doDumpOption) colorize(color, s string) string {
+	if !option.Colored || s == "" {
+		return s
+	}
+	return color + s + dumpColorReset
 }
 
 type doDumpOption struct {
-	WithType         bool
-	ExportedOnly     bool
-	DumpedPointerSet map[string]struct{}
+	DumpOption
+	Refs    *dumpRefs // Dumped reference values, for cycle detection.
+	Depth   int       // Nesting depth of current value.
+	Colored bool      // Whether dumping with ANSI colors.
+	Compact bool      // Whether dumping current value in single line.
 }
 
-func doDump(value interface{}, indent string, buffer *bytes.Buffer, option doDumpOption) {
-	if option.DumpedPointerSet == nil {
-		option.DumpedPointerSet = map[string]struct{}{}
+// getIndent returns the indent string of nested items.
+func (option doDumpOption) getIndent() string {
+	if option.Indent == "" {
+		return dumpIndent
 	}
+	return option.Indent
+}
 
+func doDump(value interface{}, indent string, buffer *bytes.Buffer, option doDumpOption) {
 	if value == nil {
-		buffer.WriteString(`<nil>`)
+		buffer.WriteString(option.colorize(dumpColorNil, `<nil>`))
 		return
 	}
 	var reflectValue reflect.Value
@@ -110,45 +214,49 @@ func doDump(value interface{}, indent string, buffer *bytes.Buffer, option doDum
 	var reflectKind = reflectValue.Kind()
 	// Double check nil value.
 	if value == nil || reflectKind == reflect.Invalid {
-		buffer.WriteString(`<nil>`)
+		buffer.WriteString(option.colorize(dumpColorNil, `<nil>`))
+		return
+	}
+	if !option.NoRedact && redact.IsType(reflectValue.Type()) {
+		doDumpRedacted(reflectValue, buffer, option)
 		return
 	}
 	var (
 		reflectTypeName = reflectValue.Type().String()
-		ptrAddress      string
-		newIndent       = indent + dumpIndent
+		newIndent       = indent + option.getIndent()
 	)
 	reflectTypeName = strings.ReplaceAll(reflectTypeName, `[]uint8`, `[]byte`)
 	for reflectKind == reflect.Ptr {
-		if ptrAddress == "" {
-			ptrAddress = fmt.Sprintf(`0x%x`, reflectValue.Pointer())
+		if !option.Refs.Mark(reflectValue, buffer) {
+			return
 		}
 		reflectValue = reflectValue.Elem()
 		reflectKind = reflectValue.Kind()
 	}
+	if !option.Refs.Mark(reflectValue, buffer) {
+		return
+	}
 	var (
 		exportInternalInput = doDumpInternalInput{
-			Value:            value,
-			Indent:           indent,
-			NewIndent:        newIndent,
-			Buffer:           buffer,
-			Option:           option,
-			PtrAddress:       ptrAddress,
-			ReflectValue:     reflectValue,
-			ReflectTypeName:  reflectTypeName,
-			ExportedOnly:     option.ExportedOnly,
-			DumpedPointerSet: option.DumpedPointerSet,
+			Value:           value,
+			Indent:          indent,
+			NewIndent:       newIndent,
+			Buffer:          buffer,
+			Option:          option,
+			ReflectValue:    reflectValue,
+			ReflectTypeName: option.colorize(dumpColorType, reflectTypeName),
+			ExportedOnly:    option.ExportedOnly,
 		}
 	)
 	switch reflectKind {
 	case reflect.Slice, reflect.Array:
-		doDumpSlice(exportInternalInput)
+		doDumpCompositive(exportInternalInput, doDumpSlice)
 
 	case reflect.Map:
-		doDumpMap(exportInternalInput)
+		doDumpCompositive(exportInternalInput, doDumpMap)
 
 	case reflect.Struct:
-		doDumpStruct(exportInternalInput)
+		doDumpCompositive(exportInternalInput, doDumpStruct)
 
 	case reflect.String:
 		doDumpString(exportInternalInput)
@@ -174,13 +282,13 @@ func doDump(value interface{}, indent string, buffer *bytes.Buffer, option doDum
 		doDumpNumber(exportInternalInput)
 
 	case reflect.Chan:
-		buffer.WriteString(fmt.Sprintf(`<%s>`, reflectValue.Type().String()))
+		buffer.WriteString(option.colorize(dumpColorType, fmt.Sprintf(`<%s>`, reflectValue.Type().String())))
 
 	case reflect.Func:
 		if reflectValue.IsNil() || !reflectValue.IsValid() {
-			buffer.WriteString(`<nil>`)
+			buffer.WriteString(option.colorize(dumpColorNil, `<nil>`))
 		} else {
-			buffer.WriteString(fmt.Sprintf(`<%s>`, reflectValue.Type().String()))
+			buffer.WriteString(option.colorize(dumpColorType, fmt.Sprintf(`<%s>`, reflectValue.Type().String())))
 		}
 
 	case reflect.Interface:
@@ -191,29 +299,136 @@ func doDump(value interface{}, indent string, buffer *bytes.Buffer, option doDum
 	}
 }
 
+// doDumpCompositive dumps slice/map/struct using `dumpFunc`, which is dumped in single line if
+// option CompactWidth is set and its single line content is not wider than it.
+func doDumpCompositive(in doDumpInternalInput, dumpFunc func(in doDumpInternalInput)) {
+	if in.Option.CompactWidth <= 0 || in.Option.Compact {
+		dumpFunc(in)
+		return
+	}
+	var (
+		start = in.Buffer.Len()
+		state = in.Option.Refs.State()
+	)
+	in.Option.Compact = true
+	dumpFunc(in)
+	if getDumpWidth(in.Buffer.Bytes()[start:]) <= in.Option.CompactWidth {
+		return
+	}
+	// Too wide, dumps it again in multiple lines.
+	in.Buffer.Truncate(start)
+	in.Option.Refs.Restore(state)
+	in.Option.Compact = false
+	dumpFunc(in)
+}
+
+// getDumpWidth returns the displayed width of dumped `content`, in which the ANSI colors are ignored.
+func getDumpWidth(content []byte) int {
+	var width int
+	for i := 0; i < len(content); {
+		if content[i] == '\x1b' {
+			for i < len(content) && content[i] != 'm' {
+				i++
+			}
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRune(content[i:])
+		i += size
+		width++
+	}
+	return width
+}
+
+// childOption returns the option for dumping the items of current value.
+func (in doDumpInternalInput) childOption() doDumpOption {
+	var option = in.Option
+	option.Depth++
+	return option
+}
+
+// elided returns the elided marker of `count` items.
+func (in doDumpInternalInput) elided(count int) string {
+	return in.Option.colorize(dumpColorNil, fmt.Sprintf(`... (%d more)`, count))
+}
+
+// isDepthExceeded checks whether the items of current value should be elided for MaxDepth.
+func (in doDumpInternalInput) isDepthExceeded() bool {
+	return in.Option.MaxDepth > 0 && in.Option.Depth >= in.Option.MaxDepth
+}
+
+// isElementsExceeded checks whether the item at `index` and the rest items should be elided
+// for MaxElements or MaxBytes.
+func (in doDumpInternalInput) isElementsExceeded(index int) bool {
+	return (in.Option.MaxElements > 0 && index >= in.Option.MaxElements) ||
+		(in.Option.MaxBytes > 0 && in.Buffer.Len() >= in.Option.MaxBytes)
+}
+
+// writeBodyStart writes the beginning of the items after the opening bracket.
+func (in doDumpInternalInput) writeBodyStart() {
+	if !in.Option.Compact {
+		in.Buffer.WriteString("\n")
+	}
+}
+
+// writeBodyEnd writes the ending of the items with the closing `bracket`.
+func (in doDumpInternalInput) writeBodyEnd(bracket string) {
+	if !in.Option.Compact {
+		in.Buffer.WriteString(in.Indent)
+	}
+	in.Buffer.WriteString(bracket)
+}
+
+// writeItemStart writes the beginning of the item at `index`, which is the indent, or the
+// separator in compact mode.
+func (in doDumpInternalInput) writeItemStart(index int) {
+	if !in.Option.Compact {
+		in.Buffer.WriteString(in.NewIndent)
+	} else if index > 0 {
+		in.Buffer.WriteString(", ")
+	}
+}
+
+// writeItemEnd writes the ending of the item with `separator`, which is omitted in compact mode.
+func (in doDumpInternalInput) writeItemEnd(separator string) {
+	if !in.Option.Compact {
+		in.Buffer.WriteString(separator + "\n")
+	}
+}
+
+// keyPadding returns the spaces after the key of `keyLength` to align the values with the longest
+// key of `maxKeyLength`, or a single space if aligning is disabled.
+func (in doDumpInternalInput) keyPadding(keyLength, maxKeyLength int) string {
+	if in.Option.Compact || in.Option.NoKeyAlign {
+		return " "
+	}
+	return strings.Repeat(" ", maxKeyLength-keyLength+1)
+}
+
 type doDumpInternalInput struct {
-	Value            interface{}
-	Indent           string
-	NewIndent        string
-	Buffer           *bytes.Buffer
-	Option           doDumpOption
-	ReflectValue     reflect.Value
-	ReflectTypeName  string
-	PtrAddress       string
-	ExportedOnly     bool
-	DumpedPointerSet map[string]struct{}
+	Value           interface{}
+	Indent          string
+	NewIndent       string
+	Buffer          *bytes.Buffer
+	Option          doDumpOption
+	ReflectValue    reflect.Value
+	ReflectTypeName string
+	ExportedOnly    bool
 }
 
 func doDumpSlice(in doDumpInternalInput) {
 	if b, ok := in.Value.([]byte); ok {
+		s, elided := truncateDumpString(string(b), in.Option.MaxStringLength)
+		elided = in.Option.colorize(dumpColorNil, elided)
 		if !in.Option.WithType {
-			in.Buffer.WriteString(fmt.Sprintf(`"%s"`, addSlashesForString(string(b))))
+			in.Buffer.WriteString(in.Option.colorize(dumpColorString, fmt.Sprintf(`"%s"`, addSlashesForString(s))) + elided)
 		} else {
 			in.Buffer.WriteString(fmt.Sprintf(
-				`%s(%d) "%s"`,
+				`%s(%d) %s%s`,
 				in.ReflectTypeName,
 				len(string(b)),
-				string(b),
+				in.Option.colorize(dumpColorString, fmt.Sprintf(`"%s"`, s)),
+				elided,
 			))
 		}
 		return
@@ -227,16 +442,26 @@ func doDumpSlice(in doDumpInternalInput) {
 		return
 	}
 	if !in.Option.WithType {
-		in.Buffer.WriteString("[\n")
+		in.Buffer.WriteString("[")
 	} else {
-		in.Buffer.WriteString(fmt.Sprintf("%s(%d) [\n", in.ReflectTypeName, in.ReflectValue.Len()))
+		in.Buffer.WriteString(fmt.Sprintf("%s(%d) [", in.ReflectTypeName, in.ReflectValue.Len()))
 	}
+	if in.isDepthExceeded() {
+		in.Buffer.WriteString(in.elided(in.ReflectValue.Len()) + "]")
+		return
+	}
+	in.writeBodyStart()
 	for i := 0; i < in.ReflectValue.Len(); i++ {
-		in.Buffer.WriteString(in.NewIndent)
-		doDump(in.ReflectValue.Index(i), in.NewIndent, in.Buffer, in.Option)
-		in.Buffer.WriteString(",\n")
+		in.writeItemStart(i)
+		if in.isElementsExceeded(i) {
+			in.Buffer.WriteString(in.elided(in.ReflectValue.Len() - i))
+			in.writeItemEnd("")
+			break
+		}
+		doDump(in.ReflectValue.Index(i), in.NewIndent, in.Buffer, in.childOption())
+		in.writeItemEnd(",")
 	}
-	in.Buffer.WriteString(fmt.Sprintf("%s]", in.Indent))
+	in.writeBodyEnd("]")
 }
 
 func doDumpMap(in doDumpInternalInput) {
@@ -248,6 +473,9 @@ func doDumpMap(in doDumpInternalInput) {
 		mapKey := key
 		mapKeys = append(mapKeys, mapKey)
 	}
+	if in.Option.SortKeys {
+		sortDumpMapKeys(mapKeys, in.Option.KeyComparator)
+	}
 	if len(mapKeys) == 0 {
 		if !in.Option.WithType {
 			in.Buffer.WriteString("{}")
@@ -268,50 +496,162 @@ func doDumpMap(in doDumpInternalInput) {
 		}
 	}
 	if !in.Option.WithType {
-		in.Buffer.WriteString("{\n")
+		in.Buffer.WriteString("{")
 	} else {
-		in.Buffer.WriteString(fmt.Sprintf("%s(%d) {\n", in.ReflectTypeName, len(mapKeys)))
+		in.Buffer.WriteString(fmt.Sprintf("%s(%d) {", in.ReflectTypeName, len(mapKeys)))
+	}
+	if in.isDepthExceeded() {
+		in.Buffer.WriteString(in.elided(len(mapKeys)) + "}")
+		return
 	}
-	for _, mapKey := range mapKeys {
+	in.writeBodyStart()
+	for i, mapKey := range mapKeys {
+		in.writeItemStart(i)
+		if in.isElementsExceeded(i) {
+			in.Buffer.WriteString(in.elided(len(mapKeys) - i))
+			in.writeItemEnd("")
+			break
+		}
 		tmpSpaceNum = len(fmt.Sprintf(`%v`, mapKey.Interface()))
 		if mapKey.Kind() == reflect.String {
 			mapKeyStr = fmt.Sprintf(`"%v"`, mapKey.Interface())
 		} else {
 			mapKeyStr = fmt.Sprintf(`%v`, mapKey.Interface())
 		}
+		mapKeyStr = in.Option.colorize(dumpColorKey, mapKeyStr)
 		// Map key and indent string dump.
 		if !in.Option.WithType {
 			in.Buffer.WriteString(fmt.Sprintf(
-				"%s%v:%s",
-				in.NewIndent,
+				"%v:%s",
 				mapKeyStr,
-				strings.Repeat(" ", maxSpaceNum-tmpSpaceNum+1),
+				in.keyPadding(tmpSpaceNum, maxSpaceNum),
 			))
 		} else {
 			in.Buffer.WriteString(fmt.Sprintf(
-				"%s%s(%v):%s",
-				in.NewIndent,
-				mapKey.Type().String(),
+				"%s(%v):%s",
+				in.Option.colorize(dumpColorType, mapKey.Type().String()),
 				mapKeyStr,
-				strings.Repeat(" ", maxSpaceNum-tmpSpaceNum+1),
+				in.keyPadding(tmpSpaceNum, maxSpaceNum),
 			))
 		}
 		// Map value dump.
-		doDump(in.ReflectValue.MapIndex(mapKey), in.NewIndent, in.Buffer, in.Option)
-		in.Buffer.WriteString(",\n")
+		if !in.Option.NoRedact && isRedactedDumpMapKey(mapKey) {
+			doDumpRedacted(in.ReflectValue.MapIndex(mapKey), in.Buffer, in.Option)
+		} else {
+			doDump(in.ReflectValue.MapIndex(mapKey), in.NewIndent, in.Buffer, in.childOption())
+		}
+		in.writeItemEnd(",")
 	}
-	in.Buffer.WriteString(fmt.Sprintf("%s}", in.Indent))
+	in.writeBodyEnd("}")
 }
 
-func doDumpStruct(in doDumpInternalInput) {
-	if in.PtrAddress != "" {
-		if _, ok := in.DumpedPointerSet[in.PtrAddress]; ok {
-			in.Buffer.WriteString(fmt.Sprintf(`<cycle dump %s>`, in.PtrAddress))
-			return
+// sortDumpMapKeys sorts map keys `keys` using `comparator`, or in default order if `comparator` is nil:
+// the numbers are sorted numerically, the strings are sorted in natural order, and the keys of
+// different kinds are sorted by their kinds.
+func sortDumpMapKeys(keys []reflect.Value, comparator Comparator) {
+	if comparator != nil {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return comparator(dumpKeyInterface(keys[i]), dumpKeyInterface(keys[j])) < 0
+		})
+		return
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return compareMapKey(keys[i], keys[j]) < 0
+	})
+}
+
+// compareMapKey compares map keys `a` and `b` in the default order of sorted map keys of this package,
+// see sortDumpMapKeys.
+func compareMapKey(a, b reflect.Value) int {
+	for a.Kind() == reflect.Interface && !a.IsNil() {
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface && !b.IsNil() {
+		b = b.Elem()
+	}
+	var (
+		aIsNumber = isNumberKind(a.Kind())
+		bIsNumber = isNumberKind(b.Kind())
+	)
+	switch {
+	case aIsNumber && bIsNumber:
+		if isIntegerKind(a.Kind()) && isIntegerKind(b.Kind()) {
+			// Compare in string format for large integers, as float64 loses precision.
+			return compareNaturalString(conv.String(dumpKeyInterface(a)), conv.String(dumpKeyInterface(b)))
+		}
+		return cmp.Compare(conv.Float64(dumpKeyInterface(a)), conv.Float64(dumpKeyInterface(b)))
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return compareNaturalString(a.String(), b.String())
+	case a.Kind() != b.Kind():
+		return cmp.Compare(a.Kind(), b.Kind())
+	default:
+		return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+	}
+}
+
+// dumpKeyInterface returns the interface of map key `key`, which might be from unexported attribute.
+func dumpKeyInterface(key reflect.Value) interface{} {
+	if value, ok := reflection.ValueToInterface(key); ok {
+		return value
+	}
+	return fmt.Sprintf("%v", key)
+}
+
+// compareNaturalString compares strings `a` and `b` in natural order, in which the digit sequences
+// are compared numerically, eg: "item2" < "item10", "-2" < "1".
+func compareNaturalString(a, b string) int {
+	var (
+		aNegative = strings.HasPrefix(a, "-") && len(a) > 1 && isDigitByte(a[1])
+		bNegative = strings.HasPrefix(b, "-") && len(b) > 1 && isDigitByte(b[1])
+	)
+	switch {
+	case aNegative && bNegative:
+		return compareNaturalString(b[1:], a[1:])
+	case aNegative:
+		return -1
+	case bNegative:
+		return 1
+	}
+	var i, j int
+	for i < len(a) && j < len(b) {
+		if isDigitByte(a[i]) && isDigitByte(b[j]) {
+			var aStart, bStart = i, j
+			for i < len(a) && isDigitByte(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigitByte(b[j]) {
+				j++
+			}
+			var (
+				aDigits = strings.TrimLeft(a[aStart:i], "0")
+				bDigits = strings.TrimLeft(b[bStart:j], "0")
+			)
+			if len(aDigits) != len(bDigits) {
+				return cmp.Compare(len(aDigits), len(bDigits))
+			}
+			if result := strings.Compare(aDigits, bDigits); result != 0 {
+				return result
+			}
+			continue
+		}
+		if a[i] != b[j] {
+			return cmp.Compare(a[i], b[j])
 		}
+		i++
+		j++
 	}
-	in.DumpedPointerSet[in.PtrAddress] = struct{}{}
+	if result := cmp.Compare(len(a)-i, len(b)-j); result != 0 {
+		return result
+	}
+	// The strings are equal in natural order, like "01" and "1".
+	return strings.Compare(a, b)
+}
 
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func doDumpStruct(in doDumpInternalInput) {
 	structFields, _ := structs.Fields(structs.FieldsInput{
 		Pointer:         in.Value,
 		RecursiveOption: structs.RecursiveOptionEmbedded,
@@ -349,6 +689,7 @@ func doDumpStruct(in doDumpInternalInput) {
 		} else {
 			structContentStr = fmt.Sprintf(`"%s"`, addSlashesForString(structContentStr))
 			attributeCountStr = fmt.Sprintf(`%d`, len(structContentStr)-2)
+			structContentStr = in.Option.colorize(dumpColorString, structContentStr)
 		}
 		if !in.Option.WithType {
 			in.Buffer.WriteString(structContentStr)
@@ -378,38 +719,54 @@ dumpStructFields:
 		}
 	}
 	if !in.Option.WithType {
-		in.Buffer.WriteString("{\n")
+		in.Buffer.WriteString("{")
 	} else {
-		in.Buffer.WriteString(fmt.Sprintf("%s(%d) {\n", in.ReflectTypeName, len(structFields)))
+		in.Buffer.WriteString(fmt.Sprintf("%s(%d) {", in.ReflectTypeName, len(structFields)))
 	}
-	for _, field := range structFields {
+	if in.isDepthExceeded() {
+		in.Buffer.WriteString(in.elided(len(structFields)) + "}")
+		return
+	}
+	in.writeBodyStart()
+	var dumpedCount = 0
+	for i, field := range structFields {
 		if in.ExportedOnly && !field.IsExported() {
 			continue
 		}
+		in.writeItemStart(dumpedCount)
+		dumpedCount++
+		if in.Option.MaxBytes > 0 && in.Buffer.Len() >= in.Option.MaxBytes {
+			in.Buffer.WriteString(in.elided(len(structFields) - i))
+			in.writeItemEnd("")
+			break
+		}
 		tmpSpaceNum = len(fmt.Sprintf(`%v`, field.Name()))
 		in.Buffer.WriteString(fmt.Sprintf(
-			"%s%s:%s",
-			in.NewIndent,
-			field.Name(),
-			strings.Repeat(" ", maxSpaceNum-tmpSpaceNum+1),
+			"%s:%s",
+			in.Option.colorize(dumpColorKey, field.Name()),
+			in.keyPadding(tmpSpaceNum, maxSpaceNum),
 		))
-		doDump(field.Value, in.NewIndent, in.Buffer, in.Option)
-		in.Buffer.WriteString(",\n")
+		if !in.Option.NoRedact && redact.IsField(field.Field) {
+			doDumpRedacted(field.Value, in.Buffer, in.Option)
+		} else {
+			doDump(field.Value, in.NewIndent, in.Buffer, in.childOption())
+		}
+		in.writeItemEnd(",")
 	}
-	in.Buffer.WriteString(fmt.Sprintf("%s}", in.Indent))
+	in.writeBodyEnd("}")
 }
 
 func doDumpNumber(in doDumpInternalInput) {
 	if v, ok := in.Value.(iString); ok {
 		s := v.String()
 		if !in.Option.WithType {
-			in.Buffer.WriteString(fmt.Sprintf(`"%v"`, addSlashesForString(s)))
+			in.Buffer.WriteString(in.Option.colorize(dumpColorString, fmt.Sprintf(`"%v"`, addSlashesForString(s))))
 		} else {
 			in.Buffer.WriteString(fmt.Sprintf(
-				`%s(%d) "%v"`,
+				`%s(%d) %s`,
 				in.ReflectTypeName,
 				len(s),
-				addSlashesForString(s),
+				in.Option.colorize(dumpColorString, fmt.Sprintf(`"%v"`, addSlashesForString(s))),
 			))
 		}
 	} else {
@@ -419,18 +776,72 @@ func doDumpNumber(in doDumpInternalInput) {
 
 func doDumpString(in doDumpInternalInput) {
 	s := in.ReflectValue.String()
+	truncated, elided := truncateDumpString(s, in.Option.MaxStringLength)
+	truncated = in.Option.colorize(dumpColorString, fmt.Sprintf(`"%v"`, addSlashesForString(truncated)))
+	elided = in.Option.colorize(dumpColorNil, elided)
 	if !in.Option.WithType {
-		in.Buffer.WriteString(fmt.Sprintf(`"%v"`, addSlashesForString(s)))
+		in.Buffer.WriteString(truncated + elided)
 	} else {
 		in.Buffer.WriteString(fmt.Sprintf(
-			`%s(%d) "%v"`,
+			`%s(%d) %s%s`,
 			in.ReflectTypeName,
 			len(s),
-			addSlashesForString(s),
+			truncated,
+			elided,
 		))
 	}
 }
 
+// truncateDumpString truncates `s` to `maxLength` characters if `maxLength` is greater than 0,
+// it also returns the elided marker if `s` is truncated.
+func truncateDumpString(s string, maxLength int) (truncated, elided string) {
+	if maxLength <= 0 || len(s) <= maxLength {
+		return s, ""
+	}
+	var runes = []rune(s)
+	if len(runes) <= maxLength {
+		return s, ""
+	}
+	return string(runes[:maxLength]), fmt.Sprintf(`... (%d more)`, len(runes)-maxLength)
+}
+
+// isRedactedDumpMapKey checks whether the value of map key `key` is secret by its name.
+func isRedactedDumpMapKey(key reflect.Value) bool {
+	if key.Kind() == reflect.Interface && !key.IsNil() {
+		key = key.Elem()
+	}
+	return key.Kind() == reflect.String && redact.IsName(key.String())
+}
+
+// doDumpRedacted dumps the secret value `reflectValue` as redact.Mask, with its type and length
+// information if option WithType is true.
+func doDumpRedacted(reflectValue reflect.Value, buffer *bytes.Buffer, option doDumpOption) {
+	var mask = option.colorize(dumpColorString, fmt.Sprintf(`"%s"`, redact.Mask))
+	if !option.WithType {
+		buffer.WriteString(mask)
+		return
+	}
+	for reflectValue.Kind() == reflect.Interface && !reflectValue.IsNil() {
+		reflectValue = reflectValue.Elem()
+	}
+	var reflectTypeName = option.colorize(
+		dumpColorType, strings.ReplaceAll(reflectValue.Type().String(), `[]uint8`, `[]byte`),
+	)
+	for reflectValue.Kind() == reflect.Ptr || reflectValue.Kind() == reflect.Interface {
+		if reflectValue.IsNil() {
+			buffer.WriteString(fmt.Sprintf(`%s(%s)`, reflectTypeName, option.colorize(dumpColorNil, `<nil>`)))
+			return
+		}
+		reflectValue = reflectValue.Elem()
+	}
+	switch reflectValue.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		buffer.WriteString(fmt.Sprintf(`%s(%d) %s`, reflectTypeName, reflectValue.Len(), mask))
+	default:
+		buffer.WriteString(fmt.Sprintf(`%s(%s)`, reflectTypeName, option.colorize(dumpColorString, redact.Mask)))
+	}
+}
+
 func doDumpBool(in doDumpInternalInput) {
 	var s string
 	if in.ReflectValue.Bool() {
@@ -438,8 +849,9 @@ func doDumpBool(in doDumpInternalInput) {
 	} else {
 		s = `false`
 	}
+	s = in.Option.colorize(dumpColorBool, s)
 	if in.Option.WithType {
-		s = fmt.Sprintf(`bool(%s)`, s)
+		s = fmt.Sprintf(`%s(%s)`, in.Option.colorize(dumpColorType, "bool"), s)
 	}
 	in.Buffer.WriteString(s)
 }
@@ -453,6 +865,12 @@ func doDumpDefault(in doDumpInternalInput) {
 		s = fmt.Sprintf("%v", in.Value)
 	}
 	s = str.Trim(s, `<>`)
+	switch {
+	case !in.ReflectValue.IsValid():
+		s = in.Option.colorize(dumpColorNil, s)
+	case isNumberKind(in.ReflectValue.Kind()):
+		s = in.Option.colorize(dumpColorNumber, s)
+	}
 	if !in.Option.WithType {
 		in.Buffer.WriteString(s)
 	} else {
@@ -470,29 +888,101 @@ func addSlashesForString(s string) string {
 }
 
 // DumpJson pretty dumps json content to stdout.
-func DumpJson(value any) {
+// The optional parameter `option` specifies the coloring behavior by its attribute Color.
+// It returns the error if `value` cannot be marshaled or is not valid json content.
+func DumpJson(value any, option ...DumpOption) error {
+	var usedOption DumpOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	return DumpJsonTo(os.Stdout, value, usedOption)
+}
+
+// DumpJsonTo pretty writes json content to `writer`.
+// It returns the error if `value` cannot be marshaled or is not valid json content,
+// or the error of writing to `writer`.
+func DumpJsonTo(writer io.Writer, value any, option DumpOption) error {
+	var jsonContent []byte
 	switch result := value.(type) {
 	case []byte:
-		doDumpJson(result)
+		jsonContent = result
 	case string:
-		doDumpJson([]byte(result))
+		jsonContent = []byte(result)
 	default:
-		jsonContent, err := json.Marshal(value)
-		if err != nil {
-			fmt.Println(err.Error())
-			return
+		var err error
+		if jsonContent, err = json.Marshal(value); err != nil {
+			return err
 		}
-		doDumpJson(jsonContent)
 	}
+	return doDumpJson(writer, jsonContent, option)
+}
+
+func doDumpJson(writer io.Writer, jsonContent []byte, option DumpOption) error {
+	var buffer = bytes.NewBuffer(nil)
+	if err := json.Indent(buffer, jsonContent, "", doDumpOption{DumpOption: option}.getIndent()); err != nil {
+		return err
+	}
+	var content = buffer.String()
+	if isDumpColored(writer, option.Color) {
+		content = colorizeDumpJson(content)
+	}
+	_, err := fmt.Fprintln(writer, content)
+	return err
 }
 
-func doDumpJson(jsonContent []byte) {
+// colorizeDumpJson colors the keys, strings, numbers, booleans and nulls of json content `content`.
+func colorizeDumpJson(content string) string {
 	var (
-		buffer    = bytes.NewBuffer(nil)
-		jsonBytes = jsonContent
+		option = doDumpOption{Colored: true}
+		buffer = bytes.NewBuffer(nil)
 	)
-	if err := json.Indent(buffer, jsonBytes, "", "    "); err != nil {
-		fmt.Println(err.Error())
+	for i := 0; i < len(content); {
+		var c = content[i]
+		switch {
+		case c == '"':
+			var j = i + 1
+			for j < len(content) && content[j] != '"' {
+				if content[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(content) {
+				j++
+			} else {
+				j = len(content)
+			}
+			var color = dumpColorString
+			if rest := strings.TrimLeft(content[j:], " \t\r\n"); strings.HasPrefix(rest, ":") {
+				color = dumpColorKey
+			}
+			buffer.WriteString(option.colorize(color, content[i:j]))
+			i = j
+
+		case c == '-' || isDigitByte(c):
+			var j = i + 1
+			for j < len(content) && strings.IndexByte("0123456789.eE+-", content[j]) >= 0 {
+				j++
+			}
+			buffer.WriteString(option.colorize(dumpColorNumber, content[i:j]))
+			i = j
+
+		case strings.HasPrefix(content[i:], "true"):
+			buffer.WriteString(option.colorize(dumpColorBool, "true"))
+			i += 4
+
+		case strings.HasPrefix(content[i:], "false"):
+			buffer.WriteString(option.colorize(dumpColorBool, "false"))
+			i += 5
+
+		case strings.HasPrefix(content[i:], "null"):
+			buffer.WriteString(option.colorize(dumpColorNil, "null"))
+			i += 4
+
+		default:
+			buffer.WriteByte(c)
+			i++
+		}
 	}
-	fmt.Println(buffer.String())
+	return buffer.String()
 }
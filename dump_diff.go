@@ -0,0 +1,387 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultDumpDiffContext = 3          // Default count of unchanged lines around changes.
+	dumpColorRemoved       = "\x1b[31m" // Red.
+	dumpColorAdded         = "\x1b[32m" // Green.
+)
+
+// DumpDiffOption specifies the behavior of function DumpDiff.
+type DumpDiffOption struct {
+	DumpOption      // DumpOption specifies dumping both values, of which the map keys are always sorted.
+	SideBySide bool // SideBySide renders the diff in two columns instead of the unified format.
+	Context    int  // Context specifies the count of unchanged lines around changes, 3 if it is 0 and none if negative.
+}
+
+// dumpDiffOp is a line operation of the edit script turning dumped lines A into B.
+type dumpDiffOp struct {
+	Kind byte // ' ' for unchanged line, '-' for removed line of A, '+' for added line of B.
+	Line string
+	A, B int // Line numbers in A and B before this operation.
+}
+
+// DumpDiff prints the difference of `a` and `b` to stdout, which are dumped with the same option.
+// It prints nothing if their dumped contents are the same.
+// Also see DumpDiffString.
+func DumpDiff(a, b interface{}, option ...DumpDiffOption) {
+	var usedOption DumpDiffOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	if isDumpColored(os.Stdout, usedOption.Color) {
+		usedOption.Color = DumpColorAlways
+	} else {
+		usedOption.Color = DumpColorNever
+	}
+	if content := DumpDiffString(a, b, usedOption); content != "" {
+		fmt.Print(content)
+	}
+}
+
+// DumpDiffString returns the difference of `a` and `b`, which are dumped with the same option,
+// or empty string if their dumped contents are the same.
+//
+// The difference starts with the changed paths that Diff returns, which are followed by the
+// changed lines of the dumped contents in unified format or side-by-side format. Only the option
+// DumpColorAlways colors the difference, as the writer is unknown.
+func DumpDiffString(a, b interface{}, option ...DumpDiffOption) string {
+	var usedOption DumpDiffOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	var (
+		dumpOption = usedOption.DumpOption
+		colored    = usedOption.Color == DumpColorAlways
+		context    = usedOption.Context
+	)
+	switch {
+	case context == 0:
+		context = defaultDumpDiffContext
+	case context < 0:
+		context = 0
+	}
+	dumpOption.Color = DumpColorNever
+	var (
+		aLines = strings.Split(DumpString(a, dumpOption), "\n")
+		bLines = strings.Split(DumpString(b, dumpOption), "\n")
+		hunks  = groupDumpDiffHunks(diffDumpLines(aLines, bLines), context)
+	)
+	if len(hunks) == 0 {
+		return ""
+	}
+	var (
+		buffer = bytes.NewBuffer(nil)
+		paint  = doDumpOption{Colored: colored}
+	)
+	if changes := Diff(a, b); len(changes) > 0 {
+		buffer.WriteString("Changed paths:\n")
+		for _, change := range changes {
+			var path = change.Path
+			if path == "" {
+				path = "<root>"
+			}
+			buffer.WriteString(fmt.Sprintf("%s%-7s %s\n", dumpIndent, change.Op, paint.colorize(dumpColorType, path)))
+		}
+	}
+	buffer.WriteString(paint.colorize(dumpColorRemoved, "--- a") + "\n")
+	buffer.WriteString(paint.colorize(dumpColorAdded, "+++ b") + "\n")
+	if usedOption.SideBySide {
+		writeDumpDiffSideBySide(buffer, hunks, paint)
+	} else {
+		writeDumpDiffUnified(buffer, hunks, paint)
+	}
+	return buffer.String()
+}
+
+// diffDumpLines returns the shortest edit script turning lines `a` into `b`, using the linear space
+// variant of Myers' algorithm, which finds the middle snake of the edit script and divides the lines.
+// The removed lines are in front of the added lines among the continuous changes.
+func diffDumpLines(a, b []string) []dumpDiffOp {
+	var (
+		size   = 2*(len(a)+len(b)) + 3
+		differ = &dumpLinesDiffer{
+			a:        a,
+			b:        b,
+			ops:      make([]dumpDiffOp, 0, len(a)+len(b)),
+			forward:  make([]int, size),
+			backward: make([]int, size),
+		}
+	)
+	differ.diff(0, len(a), 0, len(b))
+	return sortDumpDiffChanges(differ.ops)
+}
+
+// dumpLinesDiffer computes the edit script of dumped lines.
+type dumpLinesDiffer struct {
+	a, b     []string
+	ops      []dumpDiffOp
+	forward  []int // Furthest reaching x of each diagonal from the start, reused by all divisions.
+	backward []int // Furthest reaching x of each diagonal from the end, reused by all divisions.
+}
+
+// diff appends the edit script turning lines a[aStart:aEnd] into b[bStart:bEnd].
+func (d *dumpLinesDiffer) diff(aStart, aEnd, bStart, bEnd int) {
+	for aStart < aEnd && bStart < bEnd && d.a[aStart] == d.b[bStart] {
+		d.ops = append(d.ops, dumpDiffOp{Kind: ' ', Line: d.a[aStart], A: aStart, B: bStart})
+		aStart++
+		bStart++
+	}
+	var suffix = 0
+	for aStart < aEnd-suffix && bStart < bEnd-suffix && d.a[aEnd-suffix-1] == d.b[bEnd-suffix-1] {
+		suffix++
+	}
+	aEnd -= suffix
+	bEnd -= suffix
+	switch {
+	case aStart == aEnd:
+		for y := bStart; y < bEnd; y++ {
+			d.ops = append(d.ops, dumpDiffOp{Kind: '+', Line: d.b[y], A: aStart, B: y})
+		}
+
+	case bStart == bEnd:
+		for x := aStart; x < aEnd; x++ {
+			d.ops = append(d.ops, dumpDiffOp{Kind: '-', Line: d.a[x], A: x, B: bStart})
+		}
+
+	default:
+		x, y, u, v := d.middleSnake(aStart, aEnd, bStart, bEnd)
+		d.diff(aStart, x, bStart, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.ops = append(d.ops, dumpDiffOp{Kind: ' ', Line: d.a[x], A: x, B: y})
+		}
+		d.diff(u, aEnd, v, bEnd)
+	}
+	for i := 0; i < suffix; i++ {
+		d.ops = append(d.ops, dumpDiffOp{Kind: ' ', Line: d.a[aEnd+i], A: aEnd + i, B: bEnd + i})
+	}
+}
+
+// middleSnake returns the start (x, y) and end (u, v) of the middle snake of the shortest edit
+// script turning lines a[aStart:aEnd] into b[bStart:bEnd], by searching from both the start
+// and the end until the paths overlap.
+func (d *dumpLinesDiffer) middleSnake(aStart, aEnd, bStart, bEnd int) (x, y, u, v int) {
+	var (
+		n, m    = aEnd - aStart, bEnd - bStart
+		delta   = n - m
+		odd     = delta%2 != 0
+		offset  = n + m + 1
+		forward = d.forward
+		back    = d.backward
+	)
+	forward[offset+1] = 0
+	back[offset+1] = 0
+	for step := 0; step <= (n+m+1)/2; step++ {
+		for k := -step; k <= step; k += 2 {
+			var fx int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				fx = forward[offset+k+1]
+			} else {
+				fx = forward[offset+k-1] + 1
+			}
+			var (
+				fy        = fx - k
+				startX    = fx
+				startY    = fy
+				backwardK = delta - k
+			)
+			for fx < n && fy < m && d.a[aStart+fx] == d.b[bStart+fy] {
+				fx++
+				fy++
+			}
+			forward[offset+k] = fx
+			if odd && backwardK >= -(step-1) && backwardK <= step-1 && fx+back[offset+backwardK] >= n {
+				return aStart + startX, bStart + startY, aStart + fx, bStart + fy
+			}
+		}
+		for k := -step; k <= step; k += 2 {
+			var bx int
+			if k == -step || (k != step && back[offset+k-1] < back[offset+k+1]) {
+				bx = back[offset+k+1]
+			} else {
+				bx = back[offset+k-1] + 1
+			}
+			var (
+				by       = bx - k
+				startX   = bx
+				startY   = by
+				forwardK = delta - k
+			)
+			for bx < n && by < m && d.a[aEnd-1-bx] == d.b[bEnd-1-by] {
+				bx++
+				by++
+			}
+			back[offset+k] = bx
+			if !odd && forwardK >= -step && forwardK <= step && bx+forward[offset+forwardK] >= n {
+				return aEnd - bx, bEnd - by, aEnd - startX, bEnd - startY
+			}
+		}
+	}
+	// It never happens, as the paths always overlap.
+	return aStart, bStart, aStart, bStart
+}
+
+// sortDumpDiffChanges moves the removed lines in front of the added lines among the continuous
+// changes of `ops`, and updates their line numbers.
+func sortDumpDiffChanges(ops []dumpDiffOp) []dumpDiffOp {
+	var sorted = make([]dumpDiffOp, 0, len(ops))
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == ' ' {
+			sorted = append(sorted, ops[i])
+			i++
+			continue
+		}
+		var end = i
+		for end < len(ops) && ops[end].Kind != ' ' {
+			end++
+		}
+		var a, b = ops[i].A, ops[i].B
+		for _, op := range ops[i:end] {
+			if op.Kind == '-' {
+				sorted = append(sorted, dumpDiffOp{Kind: '-', Line: op.Line, A: a, B: b})
+				a++
+			}
+		}
+		for _, op := range ops[i:end] {
+			if op.Kind == '+' {
+				sorted = append(sorted, dumpDiffOp{Kind: '+', Line: op.Line, A: a, B: b})
+				b++
+			}
+		}
+		i = end
+	}
+	return sorted
+}
+
+// groupDumpDiffHunks groups the changed operations of `ops` with `context` unchanged lines around them.
+func groupDumpDiffHunks(ops []dumpDiffOp, context int) [][]dumpDiffOp {
+	var (
+		hunks = make([][]dumpDiffOp, 0)
+		start = -1 // Start index of current hunk.
+		end   = -1 // End index of current hunk, exclusive.
+	)
+	for i, op := range ops {
+		if op.Kind == ' ' {
+			continue
+		}
+		var (
+			hunkStart = i - context
+			hunkEnd   = i + context + 1
+		)
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+		if start >= 0 && hunkStart <= end {
+			end = hunkEnd
+			continue
+		}
+		if start >= 0 {
+			hunks = append(hunks, ops[start:end])
+		}
+		start, end = hunkStart, hunkEnd
+	}
+	if start >= 0 {
+		hunks = append(hunks, ops[start:end])
+	}
+	return hunks
+}
+
+// dumpDiffHunkHeader returns the unified hunk header like "@@ -1,3 +1,4 @@".
+func dumpDiffHunkHeader(hunk []dumpDiffOp) string {
+	var aCount, bCount int
+	for _, op := range hunk {
+		if op.Kind != '+' {
+			aCount++
+		}
+		if op.Kind != '-' {
+			bCount++
+		}
+	}
+	var aStart, bStart = hunk[0].A, hunk[0].B
+	if aCount > 0 {
+		aStart++
+	}
+	if bCount > 0 {
+		bStart++
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, aCount, bStart, bCount)
+}
+
+func writeDumpDiffUnified(buffer *bytes.Buffer, hunks [][]dumpDiffOp, paint doDumpOption) {
+	for _, hunk := range hunks {
+		buffer.WriteString(paint.colorize(dumpColorType, dumpDiffHunkHeader(hunk)) + "\n")
+		for _, op := range hunk {
+			var line = string(op.Kind) + op.Line
+			switch op.Kind {
+			case '-':
+				line = paint.colorize(dumpColorRemoved, line)
+			case '+':
+				line = paint.colorize(dumpColorAdded, line)
+			}
+			buffer.WriteString(line + "\n")
+		}
+	}
+}
+
+// writeDumpDiffSideBySide writes hunks in two columns, in which the changed lines are marked with
+// '|', the removed lines are marked with '<' and the added lines are marked with '>'.
+func writeDumpDiffSideBySide(buffer *bytes.Buffer, hunks [][]dumpDiffOp, paint doDumpOption) {
+	var width int
+	for _, hunk := range hunks {
+		for _, op := range hunk {
+			if lineWidth := utf8.RuneCountInString(op.Line); op.Kind != '+' && lineWidth > width {
+				width = lineWidth
+			}
+		}
+	}
+	var writeRow = func(left, mark, right, leftColor, rightColor string) {
+		var padding = strings.Repeat(" ", width-utf8.RuneCountInString(left))
+		buffer.WriteString(strings.TrimRight(fmt.Sprintf(
+			"%s%s %s %s",
+			paint.colorize(leftColor, left), padding, mark, paint.colorize(rightColor, right),
+		), " ") + "\n")
+	}
+	for _, hunk := range hunks {
+		buffer.WriteString(paint.colorize(dumpColorType, dumpDiffHunkHeader(hunk)) + "\n")
+		for i := 0; i < len(hunk); {
+			if hunk[i].Kind == ' ' {
+				writeRow(hunk[i].Line, " ", hunk[i].Line, "", "")
+				i++
+				continue
+			}
+			// Pairs the removed and added lines of the same change block.
+			var removed, added []string
+			for ; i < len(hunk) && hunk[i].Kind != ' '; i++ {
+				if hunk[i].Kind == '-' {
+					removed = append(removed, hunk[i].Line)
+				} else {
+					added = append(added, hunk[i].Line)
+				}
+			}
+			for j := 0; j < len(removed) || j < len(added); j++ {
+				switch {
+				case j < len(removed) && j < len(added):
+					writeRow(removed[j], "|", added[j], dumpColorRemoved, dumpColorAdded)
+				case j < len(removed):
+					writeRow(removed[j], "<", "", dumpColorRemoved, "")
+				default:
+					writeRow("", ">", added[j], "", dumpColorAdded)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// checkDumpDiffOps checks that `ops` turns `a` into `b` with the least changes.
+func checkDumpDiffOps(t *testing.T, a, b []string, ops []dumpDiffOp) {
+	t.Helper()
+	var (
+		aLines  = make([]string, 0, len(a))
+		bLines  = make([]string, 0, len(b))
+		changes = 0
+		x, y    = 0, 0
+	)
+	for _, op := range ops {
+		if op.A != x || op.B != y {
+			t.Fatalf("unexpected line numbers of %+v, expect A %d and B %d", op, x, y)
+		}
+		switch op.Kind {
+		case ' ':
+			aLines, bLines = append(aLines, op.Line), append(bLines, op.Line)
+			x, y = x+1, y+1
+		case '-':
+			aLines = append(aLines, op.Line)
+			x++
+			changes++
+		case '+':
+			bLines = append(bLines, op.Line)
+			y++
+			changes++
+		}
+	}
+	if !slices.Equal(aLines, a) || !slices.Equal(bLines, b) {
+		t.Fatalf("the edit script does not turn %v into %v: %+v", a, b, ops)
+	}
+	// The least changes are the lines that are not in the longest common subsequence.
+	var lcs = make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	if expect := len(a) + len(b) - 2*lcs[0][0]; changes != expect {
+		t.Fatalf("expect %d changes turning %v into %v, got %d: %+v", expect, a, b, changes, ops)
+	}
+}
+
+func TestDiffDumpLines(t *testing.T) {
+	var cases = [][2][]string{
+		{{}, {}},
+		{{}, {"a", "b"}},
+		{{"a", "b"}, {}},
+		{{"a", "b", "c"}, {"a", "b", "c"}},
+		{{"a", "b", "c"}, {"a", "x", "c"}},
+		{{"a", "b", "c", "a", "b", "b", "a"}, {"c", "b", "a", "b", "a", "c"}},
+	}
+	var random = rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		var lines [2][]string
+		for j := range lines {
+			for n := random.Intn(12); n > 0; n-- {
+				lines[j] = append(lines[j], strconv.Itoa(random.Intn(4)))
+			}
+		}
+		cases = append(cases, lines)
+	}
+	for _, c := range cases {
+		checkDumpDiffOps(t, c[0], c[1], diffDumpLines(c[0], c[1]))
+	}
+}
+
+func TestDiffDumpLines_RemovedFirst(t *testing.T) {
+	var ops = diffDumpLines([]string{"a", "b", "c"}, []string{"a", "x", "y", "c"})
+	var kinds = make([]byte, 0, len(ops))
+	for _, op := range ops {
+		kinds = append(kinds, op.Kind)
+	}
+	if string(kinds) != " -++ " {
+		t.Fatalf(`expect " -++ ", got "%s"`, kinds)
+	}
+}
+
+func TestDiffDumpLines_Large(t *testing.T) {
+	var lines = make([]string, 4000)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+	}
+	// The memory is linear to the count of lines.
+	var allocs = testing.AllocsPerRun(1, func() {
+		if ops := diffDumpLines(nil, lines); len(ops) != len(lines) {
+			t.Fatalf("expect %d added lines, got %d", len(lines), len(ops))
+		}
+	})
+	if allocs > 10 {
+		t.Fatalf("expect few allocations, got %v", allocs)
+	}
+}
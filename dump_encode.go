@@ -0,0 +1,441 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/utils/redact"
+)
+
+// DumpFormat is the structured format for function DumpEncode.
+type DumpFormat string
+
+const (
+	DumpFormatJSON DumpFormat = "json" // JSON format.
+	DumpFormatYAML DumpFormat = "yaml" // YAML format.
+)
+
+// The metadata keys of DumpEncode.
+const (
+	dumpMetaType     = "$type"     // Type name of the value.
+	dumpMetaValue    = "$value"    // Value of the scalar, pointer or unrepresentable value.
+	dumpMetaItems    = "$items"    // Items of slice or array.
+	dumpMetaEntries  = "$entries"  // Entries of map whose key is not string.
+	dumpMetaKey      = "$key"      // Key of map entry.
+	dumpMetaRef      = "$ref"      // Back-reference to the value dumped before.
+	dumpMetaEncoding = "$encoding" // Encoding of $value, which is "base64" for non UTF-8 bytes.
+	dumpMetaReal     = "$real"     // Real part of complex number.
+	dumpMetaImag     = "$imag"     // Imaginary part of complex number.
+	dumpMetaLen      = "$len"      // Length of chan or redacted value.
+	dumpMetaCap      = "$cap"      // Capacity of chan.
+	dumpMetaRedacted = "$redacted" // Whether the value is redacted.
+)
+
+// yamlPlainKeyRegex matches the keys that can be written as YAML plain scalar.
+var yamlPlainKeyRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$\-]*$`)
+
+// dumpObject is the ordered JSON object of DumpEncode.
+type dumpObject []dumpObjectItem
+
+type dumpObjectItem struct {
+	Key   string
+	Value interface{} // nil, bool, string, dumpNumber, dumpObject or []interface{}.
+}
+
+// dumpNumber is the number literal of DumpEncode.
+type dumpNumber string
+
+// dumpEncoder converts value to the annotated nodes for DumpEncode.
+type dumpEncoder struct {
+	option DumpOption
+	paths  map[dumpRefKey][]string // Reference key => path where it is firstly dumped.
+}
+
+// DumpEncode encodes `value` to `format` content with type annotations, so that the dumped content
+// can be parsed and compared by tools. Any value can be encoded, including the unexported attributes,
+// cycles and the values that JSON does not support, in which:
+// 1. The structs are encoded as objects with their attributes and "$type" metadata, the maps with
+// string keys are encoded as objects with "$type" metadata, and the keys starting with '$' are
+// escaped by doubling '$'. The other maps are encoded as "$entries" list of "$key"/"$value" objects.
+// 2. The slices and arrays are encoded as objects with "$type" and "$items" metadata.
+// 3. The pointers are encoded as the values they point to, with the pointer "$type".
+// 4. The scalars of builtin types are encoded as JSON values, or else objects with "$type" and
+// "$value" metadata, so are the NaN/Inf floats, complex numbers, chans and funcs.
+// 5. The values dumped before, including the cyclic ones, are encoded as {"$ref": "#/json/pointer"}.
+//
+// The optional parameter `option` specifies the attributes ExportedOnly, NoRedact and KeyComparator,
+// and the map items are always encoded in ascending order of their keys.
+func DumpEncode(value interface{}, format DumpFormat, option ...DumpOption) ([]byte, error) {
+	var usedOption DumpOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	e := &dumpEncoder{
+		option: usedOption,
+		paths:  make(map[dumpRefKey][]string),
+	}
+	var (
+		node   = e.encode(toReflectValue(value), make([]string, 0))
+		buffer = bytes.NewBuffer(nil)
+	)
+	switch format {
+	case DumpFormatJSON:
+		writeDumpJSON(buffer, node, "")
+	case DumpFormatYAML:
+		writeDumpYAML(buffer, node, "")
+	default:
+		return nil, errors.NewCodef(codes.CodeInvalidParameter, `unsupported dump format "%s"`, format)
+	}
+	buffer.WriteString("\n")
+	return buffer.Bytes(), nil
+}
+
+func (e *dumpEncoder) encode(v reflect.Value, path []string) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	var typeName = strings.ReplaceAll(v.Type().String(), `[]uint8`, `[]byte`)
+	if !e.option.NoRedact && redact.IsType(v.Type()) {
+		return e.encodeRedacted(v)
+	}
+	if key, ok := getDumpRefKey(v); ok {
+		if refPath, ok := e.paths[key]; ok {
+			return dumpObject{{dumpMetaRef, "#" + toJSONPointer(refPath)}}
+		}
+		e.paths[key] = path
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return e.encode(v.Elem(), path)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		var elem = e.encode(v.Elem(), path)
+		if object, ok := elem.(dumpObject); ok && len(object) > 0 && object[0].Key == dumpMetaType {
+			object[0].Value = typeName
+			return object
+		}
+		return dumpObject{{dumpMetaType, typeName}, {dumpMetaValue, elem}}
+
+	case reflect.Struct:
+		if v.Type() == timeType && v.CanInterface() {
+			return dumpObject{
+				{dumpMetaType, typeName},
+				{dumpMetaValue, v.Interface().(time.Time).Format(time.RFC3339Nano)},
+			}
+		}
+		var object = dumpObject{{dumpMetaType, typeName}}
+		for i := 0; i < v.NumField(); i++ {
+			var field = v.Type().Field(i)
+			if e.option.ExportedOnly && !field.IsExported() {
+				continue
+			}
+			if !e.option.NoRedact && redact.IsField(field) {
+				object = append(object, dumpObjectItem{field.Name, e.encodeRedacted(v.Field(i))})
+				continue
+			}
+			object = append(object, dumpObjectItem{
+				field.Name, e.encode(v.Field(i), appendPath(path, field.Name)),
+			})
+		}
+		return object
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		return e.encodeMap(v, typeName, path)
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			var b = make([]byte, v.Len())
+			for i := range b {
+				b[i] = byte(v.Index(i).Uint())
+			}
+			if utf8.Valid(b) {
+				return dumpObject{{dumpMetaType, typeName}, {dumpMetaValue, string(b)}}
+			}
+			return dumpObject{
+				{dumpMetaType, typeName},
+				{dumpMetaValue, base64.StdEncoding.EncodeToString(b)},
+				{dumpMetaEncoding, "base64"},
+			}
+		}
+		var (
+			items     = make([]interface{}, v.Len())
+			itemsPath = appendPath(path, dumpMetaItems)
+		)
+		for i := 0; i < v.Len(); i++ {
+			items[i] = e.encode(v.Index(i), appendPath(itemsPath, strconv.Itoa(i)))
+		}
+		return dumpObject{{dumpMetaType, typeName}, {dumpMetaItems, items}}
+
+	case reflect.String:
+		return encodeDumpScalar(v.Type(), v.String())
+
+	case reflect.Bool:
+		return encodeDumpScalar(v.Type(), v.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return encodeDumpScalar(v.Type(), dumpNumber(strconv.FormatInt(v.Int(), 10)))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return encodeDumpScalar(v.Type(), dumpNumber(strconv.FormatUint(v.Uint(), 10)))
+
+	case reflect.Float32, reflect.Float64:
+		var f = encodeDumpFloat(v.Float(), v.Type().Bits())
+		if _, ok := f.(string); ok {
+			return dumpObject{{dumpMetaType, typeName}, {dumpMetaValue, f}}
+		}
+		return encodeDumpScalar(v.Type(), f)
+
+	case reflect.Complex64, reflect.Complex128:
+		var c = v.Complex()
+		return dumpObject{
+			{dumpMetaType, typeName},
+			{dumpMetaReal, encodeDumpFloat(real(c), v.Type().Bits()/2)},
+			{dumpMetaImag, encodeDumpFloat(imag(c), v.Type().Bits()/2)},
+		}
+
+	case reflect.Chan:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpObject{
+			{dumpMetaType, typeName},
+			{dumpMetaLen, dumpNumber(strconv.Itoa(v.Len()))},
+			{dumpMetaCap, dumpNumber(strconv.Itoa(v.Cap()))},
+		}
+
+	case reflect.Func:
+		if v.IsNil() {
+			return nil
+		}
+		var name = typeName
+		if function := runtime.FuncForPC(v.Pointer()); function != nil {
+			name = function.Name()
+		}
+		return dumpObject{{dumpMetaType, typeName}, {dumpMetaValue, name}}
+
+	default:
+		// UnsafePointer.
+		return dumpObject{{dumpMetaType, typeName}, {dumpMetaValue, fmt.Sprintf(`0x%x`, v.Pointer())}}
+	}
+}
+
+func (e *dumpEncoder) encodeMap(v reflect.Value, typeName string, path []string) interface{} {
+	var (
+		mapKeys     = v.MapKeys()
+		isStringKey = v.Type().Key().Kind() == reflect.String
+		entries     = make([]interface{}, 0, len(mapKeys))
+		object      = dumpObject{{dumpMetaType, typeName}}
+	)
+	sortDumpMapKeys(mapKeys, e.option.KeyComparator)
+	for _, mapKey := range mapKeys {
+		// The paths of references are the paths of the emitted keys, which are the escaped key of
+		// object, or the "$key"/"$value" of item in "$entries".
+		var keyPath, valuePath []string
+		if isStringKey {
+			var keyString = mapKey.String()
+			if strings.HasPrefix(keyString, "$") {
+				keyString = "$" + keyString
+			}
+			valuePath = appendPath(path, keyString)
+		} else {
+			var entryPath = appendPath(appendPath(path, dumpMetaEntries), strconv.Itoa(len(entries)))
+			keyPath = appendPath(entryPath, dumpMetaKey)
+			valuePath = appendPath(entryPath, dumpMetaValue)
+		}
+		var mapValue interface{}
+		if !e.option.NoRedact && isRedactedDumpMapKey(mapKey) {
+			mapValue = e.encodeRedacted(v.MapIndex(mapKey))
+		} else {
+			mapValue = e.encode(v.MapIndex(mapKey), valuePath)
+		}
+		if isStringKey {
+			object = append(object, dumpObjectItem{valuePath[len(valuePath)-1], mapValue})
+			continue
+		}
+		entries = append(entries, dumpObject{
+			{dumpMetaKey, e.encode(mapKey, keyPath)},
+			{dumpMetaValue, mapValue},
+		})
+	}
+	if !isStringKey {
+		object = append(object, dumpObjectItem{dumpMetaEntries, entries})
+	}
+	return object
+}
+
+// encodeRedacted encodes the secret value `v` as redact.Mask with its type and length.
+func (e *dumpEncoder) encodeRedacted(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	var object = dumpObject{
+		{dumpMetaType, strings.ReplaceAll(v.Type().String(), `[]uint8`, `[]byte`)},
+		{dumpMetaValue, redact.Mask},
+		{dumpMetaRedacted, true},
+	}
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		object = append(object, dumpObjectItem{dumpMetaLen, dumpNumber(strconv.Itoa(v.Len()))})
+	}
+	return object
+}
+
+// encodeDumpScalar returns `value` directly if `reflectType` is builtin type, or else it returns the
+// object with "$type" and "$value" metadata.
+func encodeDumpScalar(reflectType reflect.Type, value interface{}) interface{} {
+	if reflectType.PkgPath() == "" && reflectType.Name() != "" {
+		return value
+	}
+	return dumpObject{{dumpMetaType, reflectType.String()}, {dumpMetaValue, value}}
+}
+
+// encodeDumpFloat returns the dumpNumber of `f`, or string for NaN/Inf that JSON does not support.
+func encodeDumpFloat(f float64, bitSize int) interface{} {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return dumpNumber(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+func writeDumpJSON(buffer *bytes.Buffer, node interface{}, indent string) {
+	var newIndent = indent + dumpIndent
+	switch r := node.(type) {
+	case dumpObject:
+		if len(r) == 0 {
+			buffer.WriteString("{}")
+			return
+		}
+		buffer.WriteString("{\n")
+		for i, item := range r {
+			buffer.WriteString(newIndent)
+			writeDumpJSON(buffer, item.Key, newIndent)
+			buffer.WriteString(": ")
+			writeDumpJSON(buffer, item.Value, newIndent)
+			if i < len(r)-1 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(indent + "}")
+
+	case []interface{}:
+		if len(r) == 0 {
+			buffer.WriteString("[]")
+			return
+		}
+		buffer.WriteString("[\n")
+		for i, item := range r {
+			buffer.WriteString(newIndent)
+			writeDumpJSON(buffer, item, newIndent)
+			if i < len(r)-1 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(indent + "]")
+
+	case dumpNumber:
+		buffer.WriteString(string(r))
+
+	default:
+		// The nil, bool and string.
+		b, _ := json.Marshal(r)
+		buffer.Write(b)
+	}
+}
+
+// writeDumpYAML writes `node` in YAML block style, of which the nested items are indented by `indent`.
+func writeDumpYAML(buffer *bytes.Buffer, node interface{}, indent string) {
+	var newIndent = indent + "  "
+	switch r := node.(type) {
+	case dumpObject:
+		if len(r) == 0 {
+			buffer.WriteString("{}")
+			return
+		}
+		for i, item := range r {
+			if i > 0 {
+				buffer.WriteString("\n" + indent)
+			}
+			if yamlPlainKeyRegex.MatchString(item.Key) && !isYAMLReservedScalar(item.Key) {
+				buffer.WriteString(item.Key + ":")
+			} else {
+				writeDumpJSON(buffer, item.Key, "")
+				buffer.WriteString(":")
+			}
+			switch value := item.Value.(type) {
+			case dumpObject:
+				if len(value) > 0 {
+					buffer.WriteString("\n" + newIndent)
+					writeDumpYAML(buffer, value, newIndent)
+					continue
+				}
+			case []interface{}:
+				if len(value) > 0 {
+					buffer.WriteString("\n" + indent)
+					writeDumpYAML(buffer, value, indent)
+					continue
+				}
+			}
+			buffer.WriteString(" ")
+			writeDumpYAML(buffer, item.Value, newIndent)
+		}
+
+	case []interface{}:
+		if len(r) == 0 {
+			buffer.WriteString("[]")
+			return
+		}
+		for i, item := range r {
+			if i > 0 {
+				buffer.WriteString("\n" + indent)
+			}
+			buffer.WriteString("- ")
+			writeDumpYAML(buffer, item, newIndent)
+		}
+
+	default:
+		// The JSON scalars are also valid YAML flow scalars.
+		writeDumpJSON(buffer, r, indent)
+	}
+}
+
+// isYAMLReservedScalar checks whether `s` is parsed as non-string scalar if it is not quoted.
+func isYAMLReservedScalar(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "null", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocarp/utils/redact"
+)
+
+// goSyntaxDumper dumps value as Go composite literal, which is used for option GoSyntax.
+type goSyntaxDumper struct {
+	buffer  *bytes.Buffer
+	option  doDumpOption
+	visited map[uintptr]struct{} // Pointers in current dumping path, for cycle detection.
+}
+
+// doDumpGoSyntax dumps `value` to `buffer` as valid Go source, in which:
+// 1. The structs, maps, slices and arrays are dumped as composite literals with package-qualified
+// type names, the pointers to them are dumped as "&T{...}".
+// 2. The map items are dumped in ascending order of their keys.
+// 3. The unexported and zero value struct fields are omitted.
+// 4. The unrepresentable values, like funcs and channels, are dumped as nil with comment.
+func doDumpGoSyntax(value interface{}, buffer *bytes.Buffer, option doDumpOption) {
+	var reflectValue reflect.Value
+	if v, ok := value.(reflect.Value); ok {
+		reflectValue = v
+	} else {
+		reflectValue = reflect.ValueOf(value)
+	}
+	d := &goSyntaxDumper{
+		buffer:  buffer,
+		option:  option,
+		visited: make(map[uintptr]struct{}),
+	}
+	d.dump(reflectValue, false, "")
+}
+
+// dump dumps `v` with `indent`. The parameter `typed` specifies whether the type of `v` is known
+// from the context, like typed struct field or slice element, in which the untyped constants can
+// be used without conversion.
+func (d *goSyntaxDumper) dump(v reflect.Value, typed bool, indent string) {
+	if !v.IsValid() {
+		d.buffer.WriteString("nil")
+		return
+	}
+	if !d.option.NoRedact && redact.IsType(v.Type()) {
+		d.dumpRedacted(v, typed, indent)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			d.buffer.WriteString("nil")
+			return
+		}
+		d.dump(v.Elem(), false, indent)
+
+	case reflect.Ptr:
+		d.dumpPointer(v, typed, indent)
+
+	case reflect.Struct:
+		if v.Type() == timeType && v.CanInterface() {
+			d.dumpTime(v.Interface().(time.Time))
+			return
+		}
+		d.dumpStruct(v, indent)
+
+	case reflect.Map:
+		if v.IsNil() {
+			d.dumpNil(v.Type(), typed)
+			return
+		}
+		d.dumpMap(v, indent)
+
+	case reflect.Slice:
+		if v.IsNil() {
+			d.dumpNil(v.Type(), typed)
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			d.buffer.WriteString(fmt.Sprintf(`%s(%s)`, goSyntaxTypeName(v.Type()), strconv.Quote(string(v.Bytes()))))
+			return
+		}
+		d.dumpList(v, indent)
+
+	case reflect.Array:
+		d.dumpList(v, indent)
+
+	case reflect.String:
+		d.dumpConstant(v.Type(), strconv.Quote(v.String()), typed, "string")
+
+	case reflect.Bool:
+		d.dumpConstant(v.Type(), strconv.FormatBool(v.Bool()), typed, "bool")
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d.dumpConstant(v.Type(), strconv.FormatInt(v.Int(), 10), typed, "int")
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		d.dumpConstant(v.Type(), strconv.FormatUint(v.Uint(), 10), typed, "int")
+
+	case reflect.Float32, reflect.Float64:
+		literal, isConstant := goSyntaxFloat(v.Float(), v.Type().Bits())
+		// Non-constant expression like math.NaN() is type of float64, which should be converted.
+		d.dumpConstant(v.Type(), literal, typed && isConstant, "float64")
+
+	case reflect.Complex64, reflect.Complex128:
+		var (
+			c                        = v.Complex()
+			realLiteral, isRealConst = goSyntaxFloat(real(c), v.Type().Bits()/2)
+			imagLiteral, isImagConst = goSyntaxFloat(imag(c), v.Type().Bits()/2)
+			literal                  = fmt.Sprintf(`complex(%s, %s)`, realLiteral, imagLiteral)
+		)
+		d.dumpConstant(v.Type(), literal, typed && isRealConst && isImagConst, "complex128")
+
+	default:
+		// Func, Chan and UnsafePointer.
+		d.dumpNil(v.Type(), typed)
+		if !v.IsNil() {
+			d.buffer.WriteString(fmt.Sprintf(` /* unrepresentable %s value */`, v.Type().String()))
+		}
+	}
+}
+
+func (d *goSyntaxDumper) dumpPointer(v reflect.Value, typed bool, indent string) {
+	if v.IsNil() {
+		d.dumpNil(v.Type(), typed)
+		return
+	}
+	var pointer = v.Pointer()
+	if _, ok := d.visited[pointer]; ok {
+		d.dumpNil(v.Type(), typed)
+		d.buffer.WriteString(fmt.Sprintf(` /* cycle 0x%x */`, pointer))
+		return
+	}
+	d.visited[pointer] = struct{}{}
+	defer delete(d.visited, pointer)
+
+	var (
+		elem          = v.Elem()
+		isCompositive bool
+	)
+	switch elem.Kind() {
+	case reflect.Struct:
+		isCompositive = elem.Type() != timeType
+	case reflect.Array:
+		isCompositive = true
+	case reflect.Slice, reflect.Map:
+		isCompositive = !elem.IsNil()
+	}
+	if isCompositive {
+		d.buffer.WriteString("&")
+		d.dump(elem, true, indent)
+		return
+	}
+	// There's no address operator for constants and function calls in Go.
+	var elemTypeName = goSyntaxTypeName(elem.Type())
+	d.buffer.WriteString(fmt.Sprintf(`func() *%s { var v %s = `, elemTypeName, elemTypeName))
+	d.dump(elem, true, indent)
+	d.buffer.WriteString(`; return &v }()`)
+}
+
+func (d *goSyntaxDumper) dumpStruct(v reflect.Value, indent string) {
+	var (
+		reflectType = v.Type()
+		newIndent   = indent + d.option.getIndent()
+		hasField    bool
+	)
+	d.buffer.WriteString(goSyntaxTypeName(reflectType) + "{")
+	for i := 0; i < v.NumField(); i++ {
+		var (
+			field      = reflectType.Field(i)
+			fieldValue = v.Field(i)
+		)
+		if !field.IsExported() || fieldValue.IsZero() {
+			continue
+		}
+		if !hasField {
+			d.buffer.WriteString("\n")
+			hasField = true
+		}
+		d.buffer.WriteString(fmt.Sprintf("%s%s: ", newIndent, field.Name))
+		if !d.option.NoRedact && redact.IsField(field) {
+			d.dumpRedacted(fieldValue, field.Type.Kind() != reflect.Interface, newIndent)
+		} else {
+			d.dump(fieldValue, field.Type.Kind() != reflect.Interface, newIndent)
+		}
+		d.buffer.WriteString(",\n")
+	}
+	if hasField {
+		d.buffer.WriteString(indent)
+	}
+	d.buffer.WriteString("}")
+}
+
+func (d *goSyntaxDumper) dumpMap(v reflect.Value, indent string) {
+	var (
+		reflectType = v.Type()
+		keyTyped    = reflectType.Key().Kind() != reflect.Interface
+		valueTyped  = reflectType.Elem().Kind() != reflect.Interface
+		newIndent   = indent + d.option.getIndent()
+		mapKeys     = v.MapKeys()
+	)
+	d.buffer.WriteString(goSyntaxTypeName(reflectType) + "{")
+	if len(mapKeys) == 0 {
+		d.buffer.WriteString("}")
+		return
+	}
+	sortDumpMapKeys(mapKeys, d.option.KeyComparator)
+	d.buffer.WriteString("\n")
+	for _, mapKey := range mapKeys {
+		d.buffer.WriteString(newIndent)
+		d.dump(mapKey, keyTyped, newIndent)
+		d.buffer.WriteString(": ")
+		if !d.option.NoRedact && isRedactedDumpMapKey(mapKey) {
+			d.dumpRedacted(v.MapIndex(mapKey), valueTyped, newIndent)
+		} else {
+			d.dump(v.MapIndex(mapKey), valueTyped, newIndent)
+		}
+		d.buffer.WriteString(",\n")
+	}
+	d.buffer.WriteString(indent + "}")
+}
+
+func (d *goSyntaxDumper) dumpList(v reflect.Value, indent string) {
+	var (
+		elemTyped = v.Type().Elem().Kind() != reflect.Interface
+		newIndent = indent + d.option.getIndent()
+	)
+	d.buffer.WriteString(goSyntaxTypeName(v.Type()) + "{")
+	if v.Len() == 0 {
+		d.buffer.WriteString("}")
+		return
+	}
+	d.buffer.WriteString("\n")
+	for i := 0; i < v.Len(); i++ {
+		d.buffer.WriteString(newIndent)
+		d.dump(v.Index(i), elemTyped, newIndent)
+		d.buffer.WriteString(",\n")
+	}
+	d.buffer.WriteString(indent + "}")
+}
+
+func (d *goSyntaxDumper) dumpTime(t time.Time) {
+	if t.IsZero() {
+		d.buffer.WriteString("time.Time{}")
+		return
+	}
+	var location string
+	switch t.Location() {
+	case time.UTC:
+		location = "time.UTC"
+	case time.Local:
+		location = "time.Local"
+	default:
+		name, offset := t.Zone()
+		location = fmt.Sprintf(`time.FixedZone(%s, %d)`, strconv.Quote(name), offset)
+	}
+	d.buffer.WriteString(fmt.Sprintf(
+		`time.Date(%d, time.%s, %d, %d, %d, %d, %d, %s)`,
+		t.Year(), t.Month().String(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), location,
+	))
+}
+
+// dumpRedacted dumps the secret value `v` as redact.Mask if it is string, or else its zero value.
+func (d *goSyntaxDumper) dumpRedacted(v reflect.Value, typed bool, indent string) {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+		typed = false
+	}
+	if !v.IsValid() {
+		d.buffer.WriteString("nil")
+		return
+	}
+	if v.Kind() == reflect.String {
+		d.dumpConstant(v.Type(), strconv.Quote(redact.Mask), typed, "string")
+		return
+	}
+	d.dump(reflect.Zero(v.Type()), typed, indent)
+	d.buffer.WriteString(" /* redacted */")
+}
+
+// dumpNil dumps nil value of type `reflectType`, which is converted if it is not `typed`.
+func (d *goSyntaxDumper) dumpNil(reflectType reflect.Type, typed bool) {
+	if typed {
+		d.buffer.WriteString("nil")
+		return
+	}
+	d.buffer.WriteString(fmt.Sprintf(`(%s)(nil)`, goSyntaxTypeName(reflectType)))
+}
+
+// dumpConstant dumps constant `literal` of type `reflectType`, which is converted if it is not
+// `typed` and its type is not the default type `defaultTypeName` of the constant.
+func (d *goSyntaxDumper) dumpConstant(reflectType reflect.Type, literal string, typed bool, defaultTypeName string) {
+	if typed || reflectType.String() == defaultTypeName {
+		d.buffer.WriteString(literal)
+		return
+	}
+	d.buffer.WriteString(fmt.Sprintf(`%s(%s)`, goSyntaxTypeName(reflectType), literal))
+}
+
+// goSyntaxTypeName returns the package-qualified type name of `reflectType`.
+func goSyntaxTypeName(reflectType reflect.Type) string {
+	return strings.ReplaceAll(reflectType.String(), `[]uint8`, `[]byte`)
+}
+
+// goSyntaxFloat returns the Go literal of float `f`, and whether it is a constant literal.
+func goSyntaxFloat(f float64, bitSize int) (literal string, isConstant bool) {
+	switch {
+	case math.IsNaN(f):
+		return "math.NaN()", false
+	case math.IsInf(f, 1):
+		return "math.Inf(1)", false
+	case math.IsInf(f, -1):
+		return "math.Inf(-1)", false
+	}
+	literal = strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(literal, ".eE") {
+		literal += ".0"
+	}
+	return literal, true
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// dumpLogValuer implements slog.LogValuer, which dumps the value only if it is going to be logged.
+type dumpLogValuer struct {
+	value  interface{}
+	option DumpOption
+}
+
+// DumpLogValue returns a slog.LogValuer that resolves `value` to its dumped content lazily,
+// so the dumping costs nothing if the log record is discarded, eg:
+// slog.Debug("request", "body", DumpLogValue(body)).
+// The dumped content is never colored and its map items are in ascending order of their keys.
+func DumpLogValue(value interface{}, option ...DumpOption) slog.LogValuer {
+	var usedOption DumpOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	usedOption.Color = DumpColorNever
+	return dumpLogValuer{
+		value:  value,
+		option: usedOption,
+	}
+}
+
+// LogValue implements interface slog.LogValuer.
+func (v dumpLogValuer) LogValue() slog.Value {
+	return slog.StringValue(DumpString(v.value, v.option))
+}
+
+// DumpLogger dumps values to its writer, each of which is headed with the file:line of its caller.
+// It is concurrently safe.
+type DumpLogger struct {
+	mu     sync.Mutex
+	writer io.Writer
+	option DumpOption
+}
+
+// NewDumpLogger creates and returns a DumpLogger writing to `writer`, or stdout if it is nil.
+// The optional parameter `option` specifies the dumping behaviors, see DumpOption.
+func NewDumpLogger(writer io.Writer, option ...DumpOption) *DumpLogger {
+	if writer == nil {
+		writer = os.Stdout
+	}
+	var usedOption DumpOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	return &DumpLogger{
+		writer: writer,
+		option: usedOption,
+	}
+}
+
+// Dump writes `values` to the writer of the logger, which are headed with the file:line of the
+// caller like "[main.go:12]". It returns the error of writing to the writer.
+func (l *DumpLogger) Dump(values ...interface{}) error {
+	var (
+		buffer = bytes.NewBuffer(nil)
+		option = doDumpOption{
+			DumpOption: l.option,
+			Colored:    isDumpColored(l.writer, l.option.Color),
+		}
+	)
+	if _, file, line, ok := runtime.Caller(1); ok {
+		buffer.WriteString(option.colorize(dumpColorNil, fmt.Sprintf(`[%s:%d]`, filepath.Base(file), line)))
+		buffer.WriteString("\n")
+	}
+	for _, value := range values {
+		doDumpTo(value, buffer, option)
+		buffer.WriteString("\n")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.writer.Write(buffer.Bytes())
+	return err
+}
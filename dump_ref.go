@@ -0,0 +1,135 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// dumpRefs records the dumped reference values, which are pointers, maps and slices, so that the
+// values dumped again, including the cyclic ones, are dumped as back-references like "<ref #1>".
+// The referenced values are marked with their ids like "#1" where they are firstly dumped, and the
+// ids are numbered in dumping order, which are stable for the same value if its map keys are sorted.
+type dumpRefs struct {
+	ids     map[dumpRefKey]int // Reference key => internal id in dumping order.
+	keys    []dumpRefKey       // Reference keys in dumping order, of which the index is internal id - 1.
+	patches []dumpRefPatch     // Marks to be written into dumped content, in offset order.
+}
+
+// dumpRefsState is the recording state of dumpRefs, which is used for discarding the values
+// dumped after it, eg: the composite that is dumped in compact mode at first but turns out too wide.
+type dumpRefsState struct {
+	keyCount   int
+	patchCount int
+}
+
+// dumpRefKey identifies a reference value, the type and length are used as the pointer of struct
+// and its first field, or the slices sharing the same backing array, have the same address.
+type dumpRefKey struct {
+	Type    reflect.Type
+	Pointer uintptr
+	Len     int
+}
+
+// dumpRefPatch is the mark at Offset of dumped content, which is the value definition if IsRef is
+// false, or else the back-reference to value of ID.
+type dumpRefPatch struct {
+	Offset int
+	ID     int
+	IsRef  bool
+}
+
+func newDumpRefs() *dumpRefs {
+	return &dumpRefs{
+		ids: make(map[dumpRefKey]int),
+	}
+}
+
+// State returns the current recording state, which can be restored by Restore.
+func (r *dumpRefs) State() dumpRefsState {
+	return dumpRefsState{
+		keyCount:   len(r.keys),
+		patchCount: len(r.patches),
+	}
+}
+
+// Restore discards the values recorded after `state`, as their dumped content is discarded.
+func (r *dumpRefs) Restore(state dumpRefsState) {
+	for _, key := range r.keys[state.keyCount:] {
+		delete(r.ids, key)
+	}
+	r.keys = r.keys[:state.keyCount]
+	r.patches = r.patches[:state.patchCount]
+}
+
+// Mark records `reflectValue` that is going to be dumped into `buffer`. It returns false if
+// `reflectValue` is dumped before, which should not be dumped again but as a back-reference.
+func (r *dumpRefs) Mark(reflectValue reflect.Value, buffer *bytes.Buffer) bool {
+	key, ok := getDumpRefKey(reflectValue)
+	if !ok {
+		return true
+	}
+	if id, ok := r.ids[key]; ok {
+		r.patches = append(r.patches, dumpRefPatch{Offset: buffer.Len(), ID: id, IsRef: true})
+		return false
+	}
+	var id = len(r.keys) + 1
+	r.ids[key] = id
+	r.keys = append(r.keys, key)
+	r.patches = append(r.patches, dumpRefPatch{Offset: buffer.Len(), ID: id})
+	return true
+}
+
+// WriteTo writes dumped `content` into `buffer` with the marks of referenced values and their
+// back-references, of which the ids are renumbered sequentially.
+func (r *dumpRefs) WriteTo(buffer *bytes.Buffer, content []byte, option doDumpOption) {
+	var (
+		offset     int
+		sequence   = make(map[int]int)      // Internal id => displayed id.
+		referenced = make(map[int]struct{}) // Internal ids of the values that are referenced.
+	)
+	for _, patch := range r.patches {
+		if patch.IsRef {
+			referenced[patch.ID] = struct{}{}
+		}
+	}
+	for _, patch := range r.patches {
+		if _, ok := referenced[patch.ID]; !ok {
+			continue
+		}
+		buffer.Write(content[offset:patch.Offset])
+		offset = patch.Offset
+		if patch.IsRef {
+			buffer.WriteString(option.colorize(dumpColorRef, fmt.Sprintf(`<ref #%d>`, sequence[patch.ID])))
+			continue
+		}
+		sequence[patch.ID] = len(sequence) + 1
+		buffer.WriteString(option.colorize(dumpColorRef, fmt.Sprintf(`#%d`, sequence[patch.ID])) + " ")
+	}
+	buffer.Write(content[offset:])
+}
+
+// getDumpRefKey returns the reference key of `reflectValue` if it is non-nil pointer, map or slice.
+// The byte slices are not checked as they are dumped as strings.
+func getDumpRefKey(reflectValue reflect.Value) (key dumpRefKey, ok bool) {
+	switch reflectValue.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if reflectValue.IsNil() {
+			return key, false
+		}
+	case reflect.Slice:
+		if reflectValue.Len() == 0 || reflectValue.Type().Elem().Kind() == reflect.Uint8 {
+			return key, false
+		}
+		key.Len = reflectValue.Len()
+	default:
+		return key, false
+	}
+	key.Type = reflectValue.Type()
+	key.Pointer = reflectValue.Pointer()
+	return key, true
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type dumpRefNode struct {
+	Name string
+	Next interface{}
+}
+
+func TestDumpRefs_Cycles(t *testing.T) {
+	var selfMap = map[string]interface{}{"a": 1}
+	selfMap["self"] = selfMap
+
+	var selfSlice = make([]interface{}, 2)
+	selfSlice[0] = 1
+	selfSlice[1] = selfSlice
+
+	var (
+		a = &dumpRefNode{Name: "a"}
+		b = &dumpRefNode{Name: "b", Next: a}
+	)
+	a.Next = b
+
+	var cases = []struct {
+		name   string
+		value  interface{}
+		expect string
+	}{
+		{
+			name:   "map contains itself",
+			value:  selfMap,
+			expect: "#1 {\n    \"a\":    1,\n    \"self\": <ref #1>,\n}",
+		},
+		{
+			name:   "slice contains itself",
+			value:  selfSlice,
+			expect: "#1 [\n    1,\n    <ref #1>,\n]",
+		},
+		{
+			name:   "pointer cycle through interface",
+			value:  a,
+			expect: "#1 {\n    Name: \"a\",\n    Next: {\n        Name: \"b\",\n        Next: <ref #1>,\n    },\n}",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if content := DumpString(c.value); content != c.expect {
+				t.Fatalf("\nexpect:\n%s\nactual:\n%s", c.expect, content)
+			}
+		})
+	}
+}
+
+func TestDumpRefs_Shared(t *testing.T) {
+	var (
+		shared = &dumpRefNode{Name: "s"}
+		value  = map[string]interface{}{
+			"first":  shared,
+			"second": shared,
+			"list":   []int{1, 2},
+		}
+		expect = "{\n" +
+			"    \"first\":  #1 {\n        Name: \"s\",\n        Next: <nil>,\n    },\n" +
+			"    \"list\":   [\n        1,\n        2,\n    ],\n" +
+			"    \"second\": <ref #1>,\n" +
+			"}"
+	)
+	// The values that are not referenced again, like "list", have no marks.
+	if content := DumpString(value); content != expect {
+		t.Fatalf("\nexpect:\n%s\nactual:\n%s", expect, content)
+	}
+}
+
+func TestDumpRefs_CompactWidth(t *testing.T) {
+	var (
+		short = &dumpRefNode{Name: "s"}
+		long  = &dumpRefNode{Name: strings.Repeat("x", 32)}
+	)
+	// The single line content fits the width.
+	var expect = `[#1 {Name: "s", Next: <nil>}, <ref #1>]`
+	if content := DumpString([]interface{}{short, short}, DumpOption{CompactWidth: 60}); content != expect {
+		t.Fatalf("\nexpect:\n%s\nactual:\n%s", expect, content)
+	}
+	// The single line content is too wide, of which the recorded references are discarded, and the
+	// value is dumped again in multiple lines with the same marks.
+	expect = "[\n    #1 {\n        Name: \"" + strings.Repeat("x", 32) + "\",\n        Next: <nil>,\n    },\n    <ref #1>,\n]"
+	if content := DumpString([]interface{}{long, long}, DumpOption{CompactWidth: 30}); content != expect {
+		t.Fatalf("\nexpect:\n%s\nactual:\n%s", expect, content)
+	}
+}
+
+func TestDumpRefs_Restore(t *testing.T) {
+	var (
+		refs   = newDumpRefs()
+		buffer = bytes.NewBuffer(nil)
+		first  = reflect.ValueOf(&dumpRefNode{Name: "first"})
+		second = reflect.ValueOf(&dumpRefNode{Name: "second"})
+	)
+	if !refs.Mark(first, buffer) {
+		t.Fatal("expect first value not dumped before")
+	}
+	var state = refs.State()
+	if !refs.Mark(second, buffer) {
+		t.Fatal("expect second value not dumped before")
+	}
+	if refs.Mark(first, buffer) {
+		t.Fatal("expect first value dumped before")
+	}
+	refs.Restore(state)
+	if len(refs.keys) != 1 || len(refs.ids) != 1 || len(refs.patches) != 1 {
+		t.Fatalf("expect one recorded value after restoring, got %d keys, %d ids and %d patches",
+			len(refs.keys), len(refs.ids), len(refs.patches))
+	}
+	if !refs.Mark(second, buffer) {
+		t.Fatal("expect second value discarded by restoring")
+	}
+	if refs.Mark(first, buffer) {
+		t.Fatal("expect first value kept by restoring")
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocarp/helpers/utils"
+	"github.com/gocarp/utils/conv"
+)
+
+// EqualOption specifies the option for function Equal.
+type EqualOption struct {
+	// IgnoreFields specifies the struct attributes that are not compared.
+	// The item can be attribute name or tag name in tag.StructTagPriority, which matches the attribute
+	// of any struct, or dot-separated path like that of GetPath, which supports wildcard "*".
+	IgnoreFields []string
+
+	// NilEqualsEmpty treats nil and empty slice/map as equal.
+	NilEqualsEmpty bool
+
+	// FloatTolerance is the maximum absolute difference for float values to be treated as equal.
+	FloatTolerance float64
+
+	// TimeTruncate truncates time.Time values to the duration before comparison, eg: time.Second.
+	TimeTruncate time.Duration
+
+	// UnorderedSlices compares slices/arrays ignoring the element order.
+	UnorderedSlices bool
+
+	// ConvertNumbers compares values of different numeric types, or numeric strings with numbers,
+	// by converting them to float64 using package conv.
+	ConvertNumbers bool
+}
+
+// Equal checks whether `a` and `b` are deeply equal according to `option`.
+// If they are not equal, it also returns the dot-separated path of the first difference, like that
+// of GetPath, which is empty if the difference is at the root.
+//
+// The maps are compared key by key, the structs are compared attribute by attribute, the slices
+// are compared index by index, and the time.Time values are compared using time.Time.Equal.
+// The other values are compared using reflect.DeepEqual.
+func Equal(a, b interface{}, option ...EqualOption) (equal bool, diffPath string) {
+	e := &equaler{
+		visited: make(map[equalVisitKey]struct{}),
+	}
+	if len(option) > 0 {
+		e.option = option[0]
+	}
+	for _, field := range e.option.IgnoreFields {
+		if strings.Contains(field, pathSeparator) {
+			e.ignorePaths = append(e.ignorePaths, splitPath(field))
+		}
+	}
+	var path []string
+	if path, equal = e.equal(toReflectValue(a), toReflectValue(b), make([]string, 0)); equal {
+		return true, ""
+	}
+	return false, strings.Join(path, pathSeparator)
+}
+
+type equaler struct {
+	option      EqualOption
+	ignorePaths [][]string
+	// visited contains the pointer pairs being compared, for cycle detection. The pair met again
+	// during its own comparison is treated as equal, and the result depends on the other values.
+	visited map[equalVisitKey]struct{}
+}
+
+// equalVisitKey is the identity of a pointer pair, the type is needed as the pointers to struct
+// and its first attribute are the same.
+type equalVisitKey struct {
+	Type reflect.Type
+	A, B uintptr
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (e *equaler) equal(a, b reflect.Value, path []string) ([]string, bool) {
+	for a.Kind() == reflect.Interface && !a.IsNil() {
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface && !b.IsNil() {
+		b = b.Elem()
+	}
+	if e.option.NilEqualsEmpty && isNilOrEmptyContainer(a) && isNilOrEmptyContainer(b) {
+		return nil, true
+	}
+	if !a.IsValid() || !b.IsValid() {
+		return path, a.IsValid() == b.IsValid()
+	}
+	if a.Kind() == reflect.Ptr && b.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			return path, a.IsNil() && b.IsNil()
+		}
+		var key = equalVisitKey{Type: a.Type(), A: a.Pointer(), B: b.Pointer()}
+		if _, ok := e.visited[key]; ok {
+			return nil, true
+		}
+		e.visited[key] = struct{}{}
+		defer delete(e.visited, key)
+		return e.equal(a.Elem(), b.Elem(), path)
+	}
+	if a.Type() == timeType && b.Type() == timeType {
+		var (
+			aTime = a.Interface().(time.Time)
+			bTime = b.Interface().(time.Time)
+		)
+		if e.option.TimeTruncate > 0 {
+			aTime = aTime.Truncate(e.option.TimeTruncate)
+			bTime = bTime.Truncate(e.option.TimeTruncate)
+		}
+		return path, aTime.Equal(bTime)
+	}
+	if isNumberKind(a.Kind()) && isNumberKind(b.Kind()) && (a.Type() == b.Type() || e.option.ConvertNumbers) {
+		return path, e.equalNumber(a, b)
+	}
+	if a.Type() != b.Type() {
+		if e.option.ConvertNumbers && isNumberOrNumericString(a) && isNumberOrNumericString(b) {
+			return path, e.equalNumber(a, b)
+		}
+		return path, false
+	}
+
+	switch a.Kind() {
+	case reflect.Map:
+		if a.Len() != b.Len() || a.IsNil() != b.IsNil() {
+			return path, false
+		}
+		for _, key := range sortedMapKeys(a) {
+			var keyPath = appendPath(path, conv.String(key.Interface()))
+			bValue := b.MapIndex(key)
+			if !bValue.IsValid() {
+				return keyPath, false
+			}
+			if diffPath, ok := e.equal(a.MapIndex(key), bValue, keyPath); !ok {
+				return diffPath, false
+			}
+		}
+		return nil, true
+
+	case reflect.Struct:
+		if !hasExportedField(a.Type()) {
+			return path, reflect.DeepEqual(reflectValueToInterface(a), reflectValueToInterface(b))
+		}
+		return e.equalStruct(a, b, path)
+
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice && a.IsNil() != b.IsNil() {
+			return path, false
+		}
+		if e.option.UnorderedSlices {
+			return e.equalUnordered(a, b, path)
+		}
+		for i := 0; i < a.Len() && i < b.Len(); i++ {
+			if diffPath, ok := e.equal(a.Index(i), b.Index(i), appendPath(path, strconv.Itoa(i))); !ok {
+				return diffPath, false
+			}
+		}
+		if a.Len() != b.Len() {
+			length := a.Len()
+			if b.Len() < length {
+				length = b.Len()
+			}
+			return appendPath(path, strconv.Itoa(length)), false
+		}
+		return nil, true
+
+	default:
+		return path, reflect.DeepEqual(reflectValueToInterface(a), reflectValueToInterface(b))
+	}
+}
+
+// equalStruct compares structs `a` and `b` of the same type attribute by attribute, in which the
+// attributes of embedded structs are compared as promoted attributes. The embedded struct pointers
+// are different at the embedded attribute if only one of them is nil.
+func (e *equaler) equalStruct(a, b reflect.Value, path []string) ([]string, bool) {
+	var reflectType = a.Type()
+	for i := 0; i < reflectType.NumField(); i++ {
+		var field = reflectType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		var (
+			aValue    = a.Field(i)
+			bValue    = b.Field(i)
+			fieldPath = appendPath(path, structFieldPathName(field))
+		)
+		if field.Anonymous && field.Tag == "" {
+			for aValue.Kind() == reflect.Ptr {
+				if aValue.IsNil() || bValue.IsNil() {
+					break
+				}
+				aValue, bValue = aValue.Elem(), bValue.Elem()
+			}
+			if aValue.Kind() == reflect.Ptr {
+				if aValue.IsNil() != bValue.IsNil() && !e.isIgnoredField(field, fieldPath) {
+					return fieldPath, false
+				}
+				continue
+			}
+			if aValue.Kind() == reflect.Struct {
+				if diffPath, ok := e.equalStruct(aValue, bValue, path); !ok {
+					return diffPath, false
+				}
+				continue
+			}
+		}
+		if e.isIgnoredField(field, fieldPath) {
+			continue
+		}
+		if diffPath, ok := e.equal(aValue, bValue, fieldPath); !ok {
+			return diffPath, false
+		}
+	}
+	return nil, true
+}
+
+// equalUnordered compares slices `a` and `b` ignoring the element order.
+func (e *equaler) equalUnordered(a, b reflect.Value, path []string) ([]string, bool) {
+	if a.Len() != b.Len() {
+		return path, false
+	}
+	var (
+		used = make([]bool, b.Len())
+		// The candidate comparisons use their own visited pairs, so they never affect each other.
+		candidate = &equaler{
+			option:      e.option,
+			ignorePaths: e.ignorePaths,
+		}
+	)
+	for i := 0; i < a.Len(); i++ {
+		var matched = false
+		for j := 0; j < b.Len(); j++ {
+			if used[j] {
+				continue
+			}
+			candidate.visited = make(map[equalVisitKey]struct{}, len(e.visited))
+			for key := range e.visited {
+				candidate.visited[key] = struct{}{}
+			}
+			if _, ok := candidate.equal(a.Index(i), b.Index(j), path); ok {
+				used[j] = true
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return appendPath(path, strconv.Itoa(i)), false
+		}
+	}
+	return nil, true
+}
+
+func (e *equaler) equalNumber(a, b reflect.Value) bool {
+	var (
+		aFloat = conv.Float64(a.Interface())
+		bFloat = conv.Float64(b.Interface())
+	)
+	switch {
+	case a.Kind() == b.Kind() && a.Kind() != reflect.Float32 && a.Kind() != reflect.Float64 && !e.option.ConvertNumbers:
+		return a.Interface() == b.Interface()
+	case e.option.FloatTolerance > 0:
+		return math.Abs(aFloat-bFloat) <= e.option.FloatTolerance
+	case isIntegerKind(a.Kind()) && isIntegerKind(b.Kind()):
+		// Compare in string format, as float64 loses precision for large integers.
+		return conv.String(a.Interface()) == conv.String(b.Interface())
+	default:
+		return aFloat == bFloat
+	}
+}
+
+// isIgnoredField checks whether the struct attribute `field` at `path` should be ignored.
+func (e *equaler) isIgnoredField(field reflect.StructField, path []string) bool {
+	var name = structFieldPathName(field)
+	for _, ignored := range e.option.IgnoreFields {
+		if ignored == field.Name || ignored == name {
+			return true
+		}
+	}
+	for _, pattern := range e.ignorePaths {
+		if matchPathPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNilOrEmptyContainer(reflectValue reflect.Value) bool {
+	if !reflectValue.IsValid() {
+		return true
+	}
+	switch reflectValue.Kind() {
+	case reflect.Slice, reflect.Map:
+		return reflectValue.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return reflectValue.IsNil()
+	}
+	return false
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Float32, reflect.Float64:
+		return true
+	}
+	return isIntegerKind(kind)
+}
+
+func isIntegerKind(kind reflect.Kind) bool {
+	switch kind {
+	case
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isNumberOrNumericString(reflectValue reflect.Value) bool {
+	if isNumberKind(reflectValue.Kind()) {
+		return true
+	}
+	return reflectValue.Kind() == reflect.String && utils.IsNumeric(reflectValue.String())
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"testing"
+)
+
+type equalNode struct {
+	Value int
+	Next  *equalNode
+}
+
+func TestEqual_UnorderedPointers(t *testing.T) {
+	var (
+		p = &equalNode{Value: 1}
+		q = &equalNode{Value: 2}
+		r = &equalNode{Value: 1}
+	)
+	// The pair (p, q) compared unequal should never be treated as equal when it is met again.
+	if equal, _ := Equal([]*equalNode{p, p}, []*equalNode{q, r}, EqualOption{UnorderedSlices: true}); equal {
+		t.Fatal("expect unequal slices")
+	}
+	if equal, _ := Equal([]*equalNode{p, q}, []*equalNode{q, r}, EqualOption{UnorderedSlices: true}); !equal {
+		t.Fatal("expect equal slices ignoring order")
+	}
+}
+
+func TestEqual_Cyclic(t *testing.T) {
+	var (
+		a = &equalNode{Value: 1}
+		b = &equalNode{Value: 1}
+		c = &equalNode{Value: 1}
+	)
+	a.Next = a
+	b.Next = b
+	c.Next = &equalNode{Value: 2, Next: c}
+	if equal, _ := Equal(a, b); !equal {
+		t.Fatal("expect equal cyclic values")
+	}
+	if equal, path := Equal(a, c); equal || path != "Next.Value" {
+		t.Fatalf(`expect unequal at "Next.Value", got %v at "%s"`, equal, path)
+	}
+}
@@ -3,9 +3,15 @@ module github.com/gocarp/utils
 go 1.22
 
 require (
-	github.com/gocarp/codes v1.0.0 // indirect
-	github.com/gocarp/encoding v1.0.1 // indirect
-	github.com/gocarp/errors v1.0.1 // indirect
-	github.com/gocarp/go v1.0.0 // indirect
-	github.com/gocarp/helpers v1.1.1 // indirect
+	github.com/gocarp/codes v1.0.0
+	github.com/gocarp/encoding v1.0.1
+	github.com/gocarp/errors v1.0.1
+	github.com/gocarp/go v1.0.0
+	github.com/gocarp/helpers v1.1.1
+)
+
+require (
+	github.com/gocarp/debug v1.0.0 // indirect
+	go.opentelemetry.io/otel v1.27.0 // indirect
+	go.opentelemetry.io/otel/trace v1.27.0 // indirect
 )
@@ -111,3 +111,22 @@ func MapToSlice(data interface{}) []interface{} {
 	}
 	return nil
 }
+
+// MapToSliceSorted converts map to slice of which all keys and values are its items,
+// in ascending order of the keys, of which the numbers are compared numerically and the strings
+// are compared in natural order, like the map keys of Dump.
+// Eg: {"K2": "v2", "K1": "v1"} => ["K1", "v1", "K2", "v2"]
+func MapToSliceSorted(data interface{}) []interface{} {
+	var reflectValue = toReflectValue(data)
+	for reflectValue.Kind() == reflect.Ptr && !reflectValue.IsNil() {
+		reflectValue = reflectValue.Elem()
+	}
+	if reflectValue.Kind() != reflect.Map {
+		return nil
+	}
+	array := make([]interface{}, 0, reflectValue.Len()*2)
+	for _, key := range sortedMapKeys(reflectValue) {
+		array = append(array, key.Interface(), reflectValue.MapIndex(key).Interface())
+	}
+	return array
+}
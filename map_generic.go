@@ -0,0 +1,156 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+)
+
+// MapDiffResult is the result of function MapDiff.
+type MapDiffResult[K comparable] struct {
+	Added   []K // Keys that exist only in the new map.
+	Removed []K // Keys that exist only in the old map.
+	Changed []K // Keys that exist in both maps but with different values.
+}
+
+// MapClone does a shallow copy of map `data`, which is the generic version of MapCopy.
+func MapClone[K comparable, V any](data map[K]V) map[K]V {
+	clone := make(map[K]V, len(data))
+	for k, v := range data {
+		clone[k] = v
+	}
+	return clone
+}
+
+// MapHasKey checks whether map `data` contains `key`, which is the generic version of MapContains.
+func MapHasKey[K comparable, V any](data map[K]V, key K) bool {
+	_, ok := data[key]
+	return ok
+}
+
+// MapDeleteKeys deletes all `keys` from map `data`, which is the generic version of MapDelete.
+func MapDeleteKeys[K comparable, V any](data map[K]V, keys ...K) {
+	for _, key := range keys {
+		delete(data, key)
+	}
+}
+
+// MapMergeInto merges all map from `src` to map `dst`, which is the generic version of MapMerge.
+func MapMergeInto[K comparable, V any](dst map[K]V, src ...map[K]V) {
+	if dst == nil {
+		return
+	}
+	for _, m := range src {
+		for k, v := range m {
+			dst[k] = v
+		}
+	}
+}
+
+// MapMergeClone creates and returns a new map which merges all map from `src`,
+// which is the generic version of MapMergeCopy.
+func MapMergeClone[K comparable, V any](src ...map[K]V) map[K]V {
+	clone := make(map[K]V)
+	MapMergeInto(clone, src...)
+	return clone
+}
+
+// MapCompact deletes all empty values from given map, which is the generic version of MapOmitEmpty.
+// Also see IsEmpty.
+func MapCompact[K comparable, V any](data map[K]V) {
+	for k, v := range data {
+		if IsEmpty(v) {
+			delete(data, k)
+		}
+	}
+}
+
+// MapFilter returns a new map containing the items of `data` of which `fn` returns true.
+func MapFilter[K comparable, V any](data map[K]V, fn func(k K, v V) bool) map[K]V {
+	filtered := make(map[K]V)
+	for k, v := range data {
+		if fn(k, v) {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// MapMapValues returns a new map with the same keys as `data`,
+// of which the values are converted by `fn`.
+func MapMapValues[K comparable, V any, R any](data map[K]V, fn func(k K, v V) R) map[K]R {
+	mapped := make(map[K]R, len(data))
+	for k, v := range data {
+		mapped[k] = fn(k, v)
+	}
+	return mapped
+}
+
+// MapInvert returns a new map of which the keys and values are swapped from `data`.
+// Note that if there are duplicated values in `data`, it is not determined which key is kept.
+func MapInvert[K comparable, V comparable](data map[K]V) map[V]K {
+	inverted := make(map[V]K, len(data))
+	for k, v := range data {
+		inverted[v] = k
+	}
+	return inverted
+}
+
+// MapKeysSorted returns the keys of `data` in ascending order.
+func MapKeysSorted[K cmp.Ordered, V any](data map[K]V) []K {
+	keys := make([]K, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// MapPick returns a new map containing only the items of `data` with given `keys`.
+// The keys that do not exist in `data` are ignored.
+func MapPick[K comparable, V any](data map[K]V, keys ...K) map[K]V {
+	picked := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := data[k]; ok {
+			picked[k] = v
+		}
+	}
+	return picked
+}
+
+// MapOmit returns a new map containing the items of `data` except the ones with given `keys`.
+func MapOmit[K comparable, V any](data map[K]V, keys ...K) map[K]V {
+	omitted := MapClone(data)
+	MapDeleteKeys(omitted, keys...)
+	return omitted
+}
+
+// MapDiff compares map `oldData` with `newData` and returns the added, removed and changed keys.
+// The values are compared using reflect.DeepEqual. Note that the order of returned keys is not determined.
+func MapDiff[K comparable, V any](oldData, newData map[K]V) MapDiffResult[K] {
+	result := MapDiffResult[K]{
+		Added:   make([]K, 0),
+		Removed: make([]K, 0),
+		Changed: make([]K, 0),
+	}
+	for k, oldValue := range oldData {
+		newValue, ok := newData[k]
+		if !ok {
+			result.Removed = append(result.Removed, k)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			result.Changed = append(result.Changed, k)
+		}
+	}
+	for k := range newData {
+		if _, ok := oldData[k]; !ok {
+			result.Added = append(result.Added, k)
+		}
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapToSliceSorted(t *testing.T) {
+	var cases = []struct {
+		value  interface{}
+		expect []interface{}
+	}{
+		{map[int]string{10: "b", 2: "a", -1: "c"}, []interface{}{-1, "c", 2, "a", 10, "b"}},
+		{map[string]int{"item10": 2, "item2": 1}, []interface{}{"item2", 1, "item10", 2}},
+		{map[float64]int{1.5: 2, 0.5: 1, 10: 3}, []interface{}{0.5, 1, 1.5, 2, 10.0, 3}},
+	}
+	for _, c := range cases {
+		if array := MapToSliceSorted(c.value); !reflect.DeepEqual(array, c.expect) {
+			t.Errorf("MapToSliceSorted(%v): expect %v, got %v", c.value, c.expect, array)
+		}
+	}
+}
+
+func TestKeyValues_Sorted(t *testing.T) {
+	keys, values := KeyValues(map[int]string{10: "b", 2: "a"}, KeysOption{Sorted: true})
+	if !reflect.DeepEqual(keys, []string{"2", "10"}) || !reflect.DeepEqual(values, []interface{}{"a", "b"}) {
+		t.Fatalf("unexpected keys %v and values %v", keys, values)
+	}
+	type S struct {
+		Item10 int
+		Item2  int
+	}
+	if keys = Keys(S{}, KeysOption{Sorted: true}); !reflect.DeepEqual(keys, []string{"Item2", "Item10"}) {
+		t.Fatalf("unexpected attribute keys %v", keys)
+	}
+}
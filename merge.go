@@ -0,0 +1,399 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/utils/conv"
+)
+
+// MergeStrategy specifies how MergeDeep resolves the values existing in both destination and source.
+type MergeStrategy int
+
+const (
+	MergeStrategyOverwrite    MergeStrategy = iota // Source value overwrites destination value, the default strategy.
+	MergeStrategyKeepExisting                      // Destination value is kept if it is not empty.
+	MergeStrategyAppend                            // Source slice is appended to destination slice.
+	MergeStrategyUnion                             // Source slice elements that do not exist in destination slice are appended.
+	MergeStrategyError                             // It returns an error if both values are not empty and different.
+)
+
+// MergeResolver is the custom resolving function for MergeDeep.
+// The parameter `path` is the dot-separated path of current value, like that of GetPath.
+// It returns `handled` as false to let MergeDeep resolve the values using the configured strategy.
+type MergeResolver func(path string, dst, src interface{}) (result interface{}, handled bool, err error)
+
+// MergeOption specifies the option for function MergeDeep.
+type MergeOption struct {
+	// Strategy is the default merging strategy, it is MergeStrategyOverwrite in default.
+	Strategy MergeStrategy
+
+	// PathStrategies specifies the strategies by dot-separated path, eg: "server.hosts".
+	// The path segment can be wildcard "*", which matches any key, field or index.
+	// The strategy of a path also applies to all its children, and the longest matched path wins.
+	PathStrategies map[string]MergeStrategy
+
+	// Resolver is called for each value before the strategy is applied, if it is not nil.
+	Resolver MergeResolver
+
+	// OverwriteWithEmpty specifies that the empty source values can also overwrite the destination
+	// values. In default, the empty struct attributes of source are ignored if the destination value
+	// is not empty, but the map items of source are always merged, as they are set explicitly.
+	OverwriteWithEmpty bool
+}
+
+// MergeDeep merges `src` into `dst` recursively.
+//
+// The parameter `dst` should be type of pointer to map/struct, or a non-nil map.
+// The parameter `src` can be type of map/struct or pointer to them.
+// The nested maps and structs are merged key by key, and the other values including slices are
+// resolved according to the strategies of `option`. The path of struct attribute is its tag name
+// in tag.StructTagPriority if it has one, or else its attribute name.
+//
+// It is usually used for layered configurations, eg: merge defaults, file, environment and flags
+// configurations in order by calling MergeDeep on the same `dst` for each of them.
+func MergeDeep(dst interface{}, src interface{}, option ...MergeOption) error {
+	var (
+		usedOption   MergeOption
+		reflectValue = toReflectValue(dst)
+	)
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	switch reflectValue.Kind() {
+	case reflect.Ptr:
+		if reflectValue.IsNil() {
+			return errors.NewCode(codes.CodeInvalidParameter, `the destination pointer should not be nil`)
+		}
+		reflectValue = reflectValue.Elem()
+
+	case reflect.Map:
+		if reflectValue.IsNil() {
+			return errors.NewCode(codes.CodeInvalidParameter, `the destination map should not be nil`)
+		}
+
+	default:
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid destination type "%s", should be type of pointer or map`,
+			reflectValue.Type().String(),
+		)
+	}
+	merger := &deepMerger{option: usedOption}
+	return merger.merge(reflectValue, toReflectValue(src), make([]string, 0), false)
+}
+
+type deepMerger struct {
+	option MergeOption
+}
+
+// merge merges `src` into `dst`, the `dst` should be settable or type of map.
+// The parameter `explicit` specifies that `src` is set explicitly, like the map item, of which
+// the empty value is merged like the other values.
+func (m *deepMerger) merge(dst, src reflect.Value, path []string, explicit bool) error {
+	for src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			break
+		}
+		src = src.Elem()
+	}
+	var (
+		srcEmpty = isReflectValueEmpty(src)
+		dstEmpty = isReflectValueEmpty(dst)
+	)
+	if srcEmpty && !explicit && (dstEmpty || !m.option.OverwriteWithEmpty) {
+		return nil
+	}
+	if m.option.Resolver != nil {
+		result, handled, err := m.option.Resolver(
+			strings.Join(path, pathSeparator), reflectValueToInterface(dst), reflectValueToInterface(src),
+		)
+		if err != nil {
+			return err
+		}
+		if handled {
+			return setReflectValue(dst, result)
+		}
+	}
+	// The empty value overwriting with OverwriteWithEmpty, or the explicit nil value, is resolved
+	// as a whole using the strategy, and the explicit empty composite value is merged as usual.
+	if srcEmpty && (!explicit || !src.IsValid() ||
+		((src.Kind() == reflect.Ptr || src.Kind() == reflect.Interface) && src.IsNil())) {
+		return m.resolve(dst, src, path)
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			if !dst.CanSet() {
+				return nil
+			}
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return m.merge(dst.Elem(), src, path, explicit)
+
+	case reflect.Interface:
+		if dst.IsNil() {
+			return setReflectValue(dst, Copy(reflectValueToInterface(src)))
+		}
+		var elem = dst.Elem()
+		switch elem.Kind() {
+		case reflect.Ptr, reflect.Map:
+			if isMergeableComposite(src) && isMergeableComposite(elem) {
+				return m.merge(elem, src, path, explicit)
+			}
+		default:
+			if isMergeableComposite(src) && isMergeableComposite(elem) {
+				// The element of interface is not addressable, so it merges a copy and sets it back.
+				var elemCopy = reflect.New(elem.Type()).Elem()
+				elemCopy.Set(elem)
+				if err := m.merge(elemCopy, src, path, explicit); err != nil {
+					return err
+				}
+				dst.Set(elemCopy)
+				return nil
+			}
+		}
+		return m.resolve(dst, src, path)
+
+	case reflect.Map:
+		if !isMergeableComposite(src) {
+			return m.resolve(dst, src, path)
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		return m.mergeIntoMap(dst, src, path)
+
+	case reflect.Struct:
+		if !isMergeableComposite(src) || !hasExportedField(dst.Type()) {
+			return m.resolve(dst, src, path)
+		}
+		return m.mergeIntoStruct(dst, src, path)
+
+	default:
+		return m.resolve(dst, src, path)
+	}
+}
+
+// mergeIntoMap merges the items of map/struct `src` into map `dst`.
+func (m *deepMerger) mergeIntoMap(dst, src reflect.Value, path []string) error {
+	var (
+		keyType  = dst.Type().Key()
+		elemType = dst.Type().Elem()
+	)
+	return rangeMergeSource(src, func(name string, value reflect.Value) error {
+		var key = reflect.New(keyType).Elem()
+		if err := setReflectValue(key, name); err != nil {
+			return err
+		}
+		// Map item is not addressable, so it merges a copy and sets it back.
+		var item = reflect.New(elemType).Elem()
+		if existing := dst.MapIndex(key); existing.IsValid() {
+			item.Set(existing)
+		}
+		if err := m.merge(item, value, appendPath(path, name), src.Kind() == reflect.Map); err != nil {
+			return err
+		}
+		dst.SetMapIndex(key, item)
+		return nil
+	})
+}
+
+// mergeIntoStruct merges the items of map/struct `src` into struct `dst`.
+func (m *deepMerger) mergeIntoStruct(dst, src reflect.Value, path []string) error {
+	return rangeMergeSource(src, func(name string, value reflect.Value) error {
+		field, ok := structFieldByNameOrTag(dst, name)
+		if !ok || !field.CanSet() {
+			return nil
+		}
+		return m.merge(field, value, appendPath(path, name), src.Kind() == reflect.Map)
+	})
+}
+
+// resolve resolves the non-composite values or the values that cannot be merged recursively
+// using the strategy of `path`.
+func (m *deepMerger) resolve(dst, src reflect.Value, path []string) error {
+	var dstEmpty = isReflectValueEmpty(dst)
+	switch m.strategyForPath(path) {
+	case MergeStrategyKeepExisting:
+		if !dstEmpty {
+			return nil
+		}
+
+	case MergeStrategyError:
+		if !dstEmpty && !reflect.DeepEqual(reflectValueToInterface(dst), reflectValueToInterface(src)) {
+			return errors.NewCodef(
+				codes.CodeInvalidOperation,
+				`merge conflict at path "%s"`,
+				strings.Join(path, pathSeparator),
+			)
+		}
+
+	case MergeStrategyAppend, MergeStrategyUnion:
+		var dstSlice = dst
+		for dstSlice.Kind() == reflect.Interface && !dstSlice.IsNil() {
+			dstSlice = dstSlice.Elem()
+		}
+		if dstSlice.Kind() != reflect.Slice || (src.Kind() != reflect.Slice && src.Kind() != reflect.Array) {
+			break
+		}
+		var merged = reflect.MakeSlice(dstSlice.Type(), 0, dstSlice.Len()+src.Len())
+		merged = reflect.AppendSlice(merged, dstSlice)
+		for i := 0; i < src.Len(); i++ {
+			var item = reflect.New(dstSlice.Type().Elem()).Elem()
+			if err := setReflectValue(item, Copy(reflectValueToInterface(src.Index(i)))); err != nil {
+				return err
+			}
+			if m.strategyForPath(path) == MergeStrategyUnion && sliceContainsValue(merged, item) {
+				continue
+			}
+			merged = reflect.Append(merged, item)
+		}
+		return setReflectValue(dst, merged)
+	}
+	return setReflectValue(dst, Copy(reflectValueToInterface(src)))
+}
+
+// strategyForPath returns the strategy of the longest path pattern in option that matches `path`.
+func (m *deepMerger) strategyForPath(path []string) MergeStrategy {
+	if len(m.option.PathStrategies) == 0 {
+		return m.option.Strategy
+	}
+	var patterns = make([]string, 0, len(m.option.PathStrategies))
+	for pattern := range m.option.PathStrategies {
+		patterns = append(patterns, pattern)
+	}
+	// Exact patterns are preferred, and the order of wildcard patterns is stable.
+	sort.Slice(patterns, func(i, j int) bool {
+		var (
+			iCount = strings.Count(patterns[i], pathWildcard)
+			jCount = strings.Count(patterns[j], pathWildcard)
+		)
+		if iCount != jCount {
+			return iCount < jCount
+		}
+		return patterns[i] < patterns[j]
+	})
+	for depth := len(path); depth > 0; depth-- {
+		for _, pattern := range patterns {
+			if matchPathPattern(splitPath(pattern), path[:depth]) {
+				return m.option.PathStrategies[pattern]
+			}
+		}
+	}
+	return m.option.Strategy
+}
+
+// matchPathPattern checks whether `path` matches `pattern`, in which the wildcard segment
+// matches any single segment.
+func matchPathPattern(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != pathWildcard && pattern[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rangeMergeSource calls `f` with the name and value of each item of map/struct `src`.
+// The map items are ranged in stable order, and the struct attributes are ranged by declaration.
+func rangeMergeSource(src reflect.Value, f func(name string, value reflect.Value) error) error {
+	switch src.Kind() {
+	case reflect.Map:
+		for _, key := range sortedMapKeys(src) {
+			if err := f(conv.String(key.Interface()), src.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Struct:
+		return rangeStructFields(src, func(field reflect.StructField, value reflect.Value) error {
+			return f(structFieldPathName(field), value)
+		})
+	}
+	return nil
+}
+
+// rangeStructFields calls `f` with each exported attribute of struct `reflectValue` in declaration order.
+// The attributes of embedded struct without tag are ranged as attributes of `reflectValue`.
+func rangeStructFields(reflectValue reflect.Value, f func(field reflect.StructField, value reflect.Value) error) error {
+	var reflectType = reflectValue.Type()
+	for i := 0; i < reflectValue.NumField(); i++ {
+		var fieldType = reflectType.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		var fieldValue = reflectValue.Field(i)
+		if fieldType.Anonymous && fieldType.Tag == "" {
+			for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				if err := rangeStructFields(fieldValue, f); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err := f(fieldType, fieldValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isMergeableComposite checks whether `reflectValue` is type of map/struct that can be merged recursively.
+func isMergeableComposite(reflectValue reflect.Value) bool {
+	for reflectValue.Kind() == reflect.Ptr || reflectValue.Kind() == reflect.Interface {
+		if reflectValue.IsNil() {
+			return false
+		}
+		reflectValue = reflectValue.Elem()
+	}
+	switch reflectValue.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Struct:
+		return hasExportedField(reflectValue.Type())
+	}
+	return false
+}
+
+func hasExportedField(structType reflect.Type) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		if structType.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+func isReflectValueEmpty(reflectValue reflect.Value) bool {
+	if !reflectValue.IsValid() {
+		return true
+	}
+	if !reflectValue.CanInterface() {
+		return reflectValue.IsZero()
+	}
+	return IsEmpty(reflectValue.Interface())
+}
+
+func sliceContainsValue(slice, value reflect.Value) bool {
+	for i := 0; i < slice.Len(); i++ {
+		if reflect.DeepEqual(slice.Index(i).Interface(), value.Interface()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"testing"
+)
+
+func TestMergeDeep_OverwriteWithEmptyStrategy(t *testing.T) {
+	type Config struct {
+		Host string
+		Port int
+	}
+	var cases = []struct {
+		name    string
+		option  MergeOption
+		expect  Config
+		wantErr bool
+	}{
+		{
+			name:   "overwrite",
+			option: MergeOption{OverwriteWithEmpty: true},
+			expect: Config{Host: "a", Port: 0},
+		},
+		{
+			name:   "keep existing",
+			option: MergeOption{Strategy: MergeStrategyKeepExisting, OverwriteWithEmpty: true},
+			expect: Config{Host: "a", Port: 80},
+		},
+		{
+			name: "path strategy",
+			option: MergeOption{
+				PathStrategies:     map[string]MergeStrategy{"Port": MergeStrategyKeepExisting},
+				OverwriteWithEmpty: true,
+			},
+			expect: Config{Host: "a", Port: 80},
+		},
+		{
+			name:    "error",
+			option:  MergeOption{Strategy: MergeStrategyError, OverwriteWithEmpty: true},
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var config = Config{Host: "a", Port: 80}
+			err := MergeDeep(&config, Config{Host: "a"}, c.option)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expect merge conflict error, got %#v", config)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config != c.expect {
+				t.Fatalf("\nexpect: %#v\nactual: %#v", c.expect, config)
+			}
+		})
+	}
+}
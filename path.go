@@ -0,0 +1,483 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/helpers/utils"
+	"github.com/gocarp/utils/conv"
+	"github.com/gocarp/utils/tag"
+)
+
+const (
+	pathSeparator = "."
+	pathWildcard  = "*"
+)
+
+// GetPath retrieves and returns the value from `value` specified by `path`.
+//
+// The parameter `path` is a dot-separated list of segments, eg: "orders.0.items.*.sku".
+// Each segment is resolved against the current value by its kind:
+//   - map:          the segment is converted to the map key type;
+//   - struct:       the segment matches the field name or tag name in tag.StructTagPriority;
+//   - slice/array:  the segment should be an integer index;
+//   - pointer/interface values are automatically dereferenced.
+//
+// The segment "*" is a wildcard that matches all elements of slice/array/map, or all exported
+// attributes of struct. If `path` contains any wildcard, the returned value is type of
+// []interface{} containing all matched values, and the elements that do not contain the rest
+// of the path are ignored.
+//
+// It returns an error with code codes.CodeNotFound if the path does not exist, or an error with
+// code codes.CodeInvalidParameter if any segment cannot be applied to the value type.
+func GetPath(value interface{}, path string) (interface{}, error) {
+	return getPathSegments(value, splitPath(path))
+}
+
+// getPathSegments acts as GetPath, but the path is split `segments`, which might contain the separator.
+func getPathSegments(value interface{}, segments []string) (interface{}, error) {
+	var (
+		results []reflect.Value
+		err     error
+	)
+	if results, err = doGetPath(toReflectValue(value), segments, 0); err != nil {
+		return nil, err
+	}
+	if !hasPathWildcard(segments) {
+		return reflectValueToInterface(results[0]), nil
+	}
+	var values = make([]interface{}, 0, len(results))
+	for _, result := range results {
+		values = append(values, reflectValueToInterface(result))
+	}
+	return values, nil
+}
+
+// SetPath sets `newValue` to the attribute of `pointer` specified by `path`.
+//
+// The parameter `pointer` should be type of pointer or map, as the value should be changeable.
+// The `path` follows the same rules as GetPath, the wildcard "*" sets all matched elements.
+// Nil pointers and maps on the path are automatically created, and missing items of
+// map[string]interface{} are created as nested map[string]interface{}.
+//
+// The `newValue` is assigned directly if its type is assignable to the target, or else it is
+// converted to the target type using package conv.
+func SetPath(pointer interface{}, path string, newValue interface{}) error {
+	return setPathSegments(pointer, splitPath(path), newValue)
+}
+
+// setPathSegments acts as SetPath, but the path is split `segments`, which might contain the separator.
+func setPathSegments(pointer interface{}, segments []string, newValue interface{}) error {
+	var reflectValue = toReflectValue(pointer)
+	switch reflectValue.Kind() {
+	case reflect.Ptr:
+		if reflectValue.IsNil() {
+			return errors.NewCode(codes.CodeInvalidParameter, `the pointer should not be nil`)
+		}
+		reflectValue = reflectValue.Elem()
+
+	case reflect.Map:
+		if reflectValue.IsNil() {
+			return errors.NewCode(codes.CodeInvalidParameter, `the map should not be nil`)
+		}
+
+	default:
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid parameter type "%s", should be type of pointer or map`,
+			reflectValue.Type().String(),
+		)
+	}
+	return doSetPath(reflectValue, segments, 0, newValue)
+}
+
+// splitPath splits the path into segments, ignoring empty segments.
+func splitPath(path string) []string {
+	var segments = make([]string, 0)
+	for _, segment := range strings.Split(path, pathSeparator) {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// appendPath returns a new path of `path` with `segment`, which does not share `path` memory,
+// so that the paths of sibling items do not overwrite each other.
+func appendPath(path []string, segment string) []string {
+	return append(path[:len(path):len(path)], segment)
+}
+
+func hasPathWildcard(segments []string) bool {
+	for _, segment := range segments {
+		if segment == pathWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+func toReflectValue(value interface{}) reflect.Value {
+	if v, ok := value.(reflect.Value); ok {
+		return v
+	}
+	return reflect.ValueOf(value)
+}
+
+func reflectValueToInterface(reflectValue reflect.Value) interface{} {
+	if !reflectValue.IsValid() || !reflectValue.CanInterface() {
+		return nil
+	}
+	return reflectValue.Interface()
+}
+
+func newPathNotFoundError(segments []string, index int) error {
+	return errors.NewCodef(
+		codes.CodeNotFound,
+		`path "%s" not found`,
+		strings.Join(segments[:index+1], pathSeparator),
+	)
+}
+
+func newPathTypeMismatchError(segments []string, index int, reflectValue reflect.Value) error {
+	var typeName = "nil"
+	if reflectValue.IsValid() {
+		typeName = reflectValue.Type().String()
+	}
+	return errors.NewCodef(
+		codes.CodeInvalidParameter,
+		`cannot resolve segment "%s" of path "%s" on type "%s"`,
+		segments[index], strings.Join(segments, pathSeparator), typeName,
+	)
+}
+
+// doGetPath resolves `segments` starting from `index` on `reflectValue`,
+// returning all the matched values.
+func doGetPath(reflectValue reflect.Value, segments []string, index int) ([]reflect.Value, error) {
+	if index >= len(segments) {
+		return []reflect.Value{reflectValue}, nil
+	}
+	for reflectValue.Kind() == reflect.Ptr || reflectValue.Kind() == reflect.Interface {
+		if reflectValue.IsNil() {
+			return nil, newPathNotFoundError(segments, index)
+		}
+		reflectValue = reflectValue.Elem()
+	}
+	var segment = segments[index]
+	if segment == pathWildcard {
+		elements, ok := pathWildcardElements(reflectValue)
+		if !ok {
+			return nil, newPathTypeMismatchError(segments, index, reflectValue)
+		}
+		var results = make([]reflect.Value, 0, len(elements))
+		for _, element := range elements {
+			matched, err := doGetPath(element, segments, index+1)
+			if err != nil {
+				if errors.Code(err) == codes.CodeNotFound {
+					continue
+				}
+				return nil, err
+			}
+			results = append(results, matched...)
+		}
+		return results, nil
+	}
+	element, err := pathElement(reflectValue, segments, index)
+	if err != nil {
+		return nil, err
+	}
+	return doGetPath(element, segments, index+1)
+}
+
+// doSetPath resolves `segments` starting from `index` on `reflectValue` and sets `newValue`
+// to the final matched values. The `reflectValue` should be addressable or type of map.
+func doSetPath(reflectValue reflect.Value, segments []string, index int, newValue interface{}) error {
+	if index >= len(segments) {
+		return setReflectValue(reflectValue, newValue)
+	}
+	switch reflectValue.Kind() {
+	case reflect.Ptr:
+		if reflectValue.IsNil() {
+			if !reflectValue.CanSet() {
+				return newPathNotFoundError(segments, index)
+			}
+			reflectValue.Set(reflect.New(reflectValue.Type().Elem()))
+		}
+		return doSetPath(reflectValue.Elem(), segments, index, newValue)
+
+	case reflect.Interface:
+		if reflectValue.IsNil() {
+			if !reflectValue.CanSet() || reflectValue.NumMethod() > 0 {
+				return newPathNotFoundError(segments, index)
+			}
+			reflectValue.Set(reflect.ValueOf(make(map[string]interface{})))
+		}
+		var elem = reflectValue.Elem()
+		switch elem.Kind() {
+		case reflect.Ptr, reflect.Map:
+			return doSetPath(elem, segments, index, newValue)
+		}
+		// The element of interface is not addressable,
+		// so it modifies a copy and sets it back.
+		if !reflectValue.CanSet() {
+			return newPathTypeMismatchError(segments, index, elem)
+		}
+		var elemCopy = reflect.New(elem.Type()).Elem()
+		elemCopy.Set(elem)
+		if err := doSetPath(elemCopy, segments, index, newValue); err != nil {
+			return err
+		}
+		reflectValue.Set(elemCopy)
+		return nil
+
+	case reflect.Map:
+		if reflectValue.IsNil() {
+			if !reflectValue.CanSet() {
+				return newPathNotFoundError(segments, index)
+			}
+			reflectValue.Set(reflect.MakeMap(reflectValue.Type()))
+		}
+		var keys []reflect.Value
+		if segments[index] == pathWildcard {
+			keys = sortedMapKeys(reflectValue)
+		} else {
+			key, err := pathMapKey(reflectValue, segments, index)
+			if err != nil {
+				return err
+			}
+			keys = []reflect.Value{key}
+		}
+		for _, key := range keys {
+			// Map item is not addressable, so it modifies a copy and sets it back.
+			var item = reflect.New(reflectValue.Type().Elem()).Elem()
+			if existing := reflectValue.MapIndex(key); existing.IsValid() {
+				item.Set(existing)
+			}
+			if err := doSetPath(item, segments, index+1, newValue); err != nil {
+				return err
+			}
+			reflectValue.SetMapIndex(key, item)
+		}
+		return nil
+
+	case reflect.Slice, reflect.Array, reflect.Struct:
+		if segments[index] == pathWildcard {
+			elements, _ := pathWildcardElements(reflectValue)
+			for _, element := range elements {
+				if err := doSetPath(element, segments, index+1, newValue); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		element, err := pathElement(reflectValue, segments, index)
+		if err != nil {
+			return err
+		}
+		if !element.CanSet() {
+			return newPathTypeMismatchError(segments, index, reflectValue)
+		}
+		return doSetPath(element, segments, index+1, newValue)
+
+	default:
+		return newPathTypeMismatchError(segments, index, reflectValue)
+	}
+}
+
+// pathElement retrieves the element of `reflectValue` specified by segment at `index`.
+func pathElement(reflectValue reflect.Value, segments []string, index int) (reflect.Value, error) {
+	var segment = segments[index]
+	switch reflectValue.Kind() {
+	case reflect.Map:
+		key, err := pathMapKey(reflectValue, segments, index)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		element := reflectValue.MapIndex(key)
+		if !element.IsValid() {
+			return reflect.Value{}, newPathNotFoundError(segments, index)
+		}
+		return element, nil
+
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(segment)
+		if err != nil {
+			return reflect.Value{}, newPathTypeMismatchError(segments, index, reflectValue)
+		}
+		if i < 0 || i >= reflectValue.Len() {
+			return reflect.Value{}, newPathNotFoundError(segments, index)
+		}
+		return reflectValue.Index(i), nil
+
+	case reflect.Struct:
+		if element, ok := structFieldByNameOrTag(reflectValue, segment); ok {
+			return element, nil
+		}
+		return reflect.Value{}, newPathNotFoundError(segments, index)
+
+	default:
+		return reflect.Value{}, newPathTypeMismatchError(segments, index, reflectValue)
+	}
+}
+
+// pathMapKey converts the segment at `index` to the key type of map `reflectValue`.
+func pathMapKey(reflectValue reflect.Value, segments []string, index int) (key reflect.Value, err error) {
+	var keyType = reflectValue.Type().Key()
+	switch keyType.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(segments[index]).Convert(keyType), nil
+	case reflect.Interface:
+		return reflect.ValueOf(segments[index]), nil
+	}
+	defer func() {
+		if exception := recover(); exception != nil {
+			err = newPathTypeMismatchError(segments, index, reflectValue)
+		}
+	}()
+	key = reflect.ValueOf(conv.ConvertWithRefer(segments[index], reflect.Zero(keyType)))
+	if !key.Type().ConvertibleTo(keyType) {
+		return reflect.Value{}, newPathTypeMismatchError(segments, index, reflectValue)
+	}
+	return key.Convert(keyType), nil
+}
+
+// pathWildcardElements returns all the elements of `reflectValue` for wildcard matching.
+func pathWildcardElements(reflectValue reflect.Value) ([]reflect.Value, bool) {
+	var elements = make([]reflect.Value, 0)
+	switch reflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < reflectValue.Len(); i++ {
+			elements = append(elements, reflectValue.Index(i))
+		}
+
+	case reflect.Map:
+		for _, key := range sortedMapKeys(reflectValue) {
+			elements = append(elements, reflectValue.MapIndex(key))
+		}
+
+	case reflect.Struct:
+		var reflectType = reflectValue.Type()
+		for i := 0; i < reflectValue.NumField(); i++ {
+			if reflectType.Field(i).IsExported() {
+				elements = append(elements, reflectValue.Field(i))
+			}
+		}
+
+	default:
+		return nil, false
+	}
+	return elements, true
+}
+
+// sortedMapKeys returns the keys of map `reflectValue` sorted using compareMapKey,
+// which makes the wildcard matching result stable.
+func sortedMapKeys(reflectValue reflect.Value) []reflect.Value {
+	var keys = reflectValue.MapKeys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return compareMapKey(keys[i], keys[j]) < 0
+	})
+	return keys
+}
+
+// structFieldByNameOrTag searches the exported attribute of struct `reflectValue` by `name`,
+// which can be the attribute name or the tag name in tag.StructTagPriority.
+// It also searches the attributes of embedded structs.
+func structFieldByNameOrTag(reflectValue reflect.Value, name string) (reflect.Value, bool) {
+	var (
+		fieldType   reflect.StructField
+		reflectType = reflectValue.Type()
+	)
+	for i := 0; i < reflectValue.NumField(); i++ {
+		fieldType = reflectType.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		if fieldType.Name == name || structFieldPathName(fieldType) == name {
+			return reflectValue.Field(i), true
+		}
+	}
+	for i := 0; i < reflectValue.NumField(); i++ {
+		if !reflectType.Field(i).Anonymous {
+			continue
+		}
+		var fieldValue = reflectValue.Field(i)
+		for fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				break
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Struct {
+			if v, ok := structFieldByNameOrTag(fieldValue, name); ok {
+				return v, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// structFieldPathName returns the name of struct attribute that is used in path,
+// which is its tag name in tag.StructTagPriority if it has one, or else its attribute name.
+func structFieldPathName(field reflect.StructField) string {
+	for _, tagName := range tag.StructTagPriority {
+		if tagValue := field.Tag.Get(tagName); tagValue != "" {
+			if name := utils.SplitAndTrim(tagValue, ",")[0]; name != "" && name != "-" {
+				return name
+			}
+			break
+		}
+	}
+	return field.Name
+}
+
+// setReflectValue sets `value` to `reflectValue`, which should be settable.
+// It converts `value` to the type of `reflectValue` using package conv if necessary.
+func setReflectValue(reflectValue reflect.Value, value interface{}) (err error) {
+	if !reflectValue.CanSet() {
+		return errors.NewCodef(
+			codes.CodeInvalidOperation,
+			`value of type "%s" cannot be set`,
+			reflectValue.Type().String(),
+		)
+	}
+	var valueReflectValue = toReflectValue(value)
+	if !valueReflectValue.IsValid() {
+		reflectValue.Set(reflect.Zero(reflectValue.Type()))
+		return nil
+	}
+	if valueReflectValue.Type().AssignableTo(reflectValue.Type()) {
+		reflectValue.Set(valueReflectValue)
+		return nil
+	}
+	defer func() {
+		if exception := recover(); exception != nil {
+			err = errors.NewCodef(
+				codes.CodeInvalidParameter,
+				`cannot convert value of type "%s" to type "%s": %+v`,
+				valueReflectValue.Type().String(), reflectValue.Type().String(), exception,
+			)
+		}
+	}()
+	switch reflectValue.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return conv.Scan(valueReflectValue.Interface(), reflectValue.Addr().Interface())
+	}
+	var converted = toReflectValue(conv.ConvertWithRefer(valueReflectValue.Interface(), reflectValue))
+	if !converted.IsValid() || !converted.Type().ConvertibleTo(reflectValue.Type()) {
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`cannot convert value of type "%s" to type "%s"`,
+			valueReflectValue.Type().String(), reflectValue.Type().String(),
+		)
+	}
+	reflectValue.Set(converted.Convert(reflectValue.Type()))
+	return nil
+}
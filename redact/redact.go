@@ -0,0 +1,120 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package redact provides the secret values detecting for dumping and map converting.
+//
+// A value is considered secret if:
+// 1. Its struct field has tag `dump:"-"` or `redact:"true"`, and tag `redact:"false"` disables redacting.
+// 2. Its struct field name or map key ends with any registered pattern, case-insensitively and
+// ignoring the separators '_', '-', '.' and ' ', which are "password", "passwd", "secret", "token",
+// "secret_key", "private_key" and "api_key" in default. For example, "Password", "access_token" and
+// "accessToken" are secret names, but "MaxTokens", "TokenCount" and "Tokenizer" are not.
+// 3. Its type, or the type its pointer points to, is registered by RegisterType.
+//
+// Note that calling registering functions of this package is not concurrently safe,
+// which means you cannot call them in runtime but in boot procedure.
+package redact
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gocarp/utils/tag"
+)
+
+// Mask is the fixed mask replacing the secret values.
+const Mask = "******"
+
+var (
+	// patterns are the normalized name patterns of secret struct fields and map keys, see normalizeName.
+	patterns = []string{"password", "passwd", "secret", "token", "secretkey", "privatekey", "apikey"}
+
+	// typeSet is the set of registered secret types.
+	typeSet = make(map[reflect.Type]struct{})
+)
+
+// RegisterPattern registers name patterns of secret struct fields and map keys.
+// The name matches the pattern if it ends with the pattern, case-insensitively and ignoring
+// the separators, eg: pattern "auth_code" matches "AuthCode" and "user-auth-code".
+func RegisterPattern(namePatterns ...string) {
+	for _, pattern := range namePatterns {
+		if pattern = normalizeName(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+}
+
+// RegisterType registers the types of `values` as secret types, eg: RegisterType(Password("")).
+// The `values` can also be reflect.Type.
+func RegisterType(values ...interface{}) {
+	for _, value := range values {
+		var reflectType reflect.Type
+		switch v := value.(type) {
+		case nil:
+			continue
+		case reflect.Type:
+			reflectType = v
+		default:
+			reflectType = reflect.TypeOf(value)
+		}
+		typeSet[reflectType] = struct{}{}
+	}
+}
+
+// IsName checks whether `name` of struct field or map key matches any registered pattern.
+// The name matches the pattern if it ends with the pattern, case-insensitively and ignoring
+// the separators, so that the names only containing the pattern, like "MaxTokens", do not match.
+func IsName(name string) bool {
+	if name = normalizeName(name); name == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if strings.HasSuffix(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeName returns the lower case `name` without separators '_', '-', '.' and ' '.
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', '.', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// IsType checks whether `reflectType` or the type it points to is registered secret type.
+func IsType(reflectType reflect.Type) bool {
+	if len(typeSet) == 0 || reflectType == nil {
+		return false
+	}
+	for {
+		if _, ok := typeSet[reflectType]; ok {
+			return true
+		}
+		if reflectType.Kind() != reflect.Ptr {
+			return false
+		}
+		reflectType = reflectType.Elem()
+	}
+}
+
+// IsField checks whether the value of struct field `field` is secret, by its tag, name and type.
+// The tag `redact:"false"` marks the field not secret, even if its name matches any pattern.
+func IsField(field reflect.StructField) bool {
+	switch field.Tag.Get(tag.Redact) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if field.Tag.Get(tag.Dump) == "-" {
+		return true
+	}
+	return IsName(field.Name) || IsType(field.Type)
+}
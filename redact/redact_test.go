@@ -0,0 +1,33 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package redact
+
+import (
+	"testing"
+)
+
+func TestIsName(t *testing.T) {
+	var cases = map[string]bool{
+		"Password":      true,
+		"db_password":   true,
+		"access_token":  true,
+		"accessToken":   true,
+		"X-Auth-Token":  true,
+		"ClientSecret":  true,
+		"SecretKey":     true,
+		"apikey":        true,
+		"MaxTokens":     false,
+		"TokenCount":    false,
+		"Tokenizer":     false,
+		"SecretVersion": false,
+		"Name":          false,
+		"":              false,
+	}
+	for name, expect := range cases {
+		if IsName(name) != expect {
+			t.Errorf("IsName(%q): expect %v", name, expect)
+		}
+	}
+}
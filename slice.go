@@ -7,6 +7,8 @@ package utils
 import (
 	"reflect"
 
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
 	"github.com/gocarp/utils/conv"
 )
 
@@ -44,6 +46,10 @@ func SliceInsertAfter(slice []interface{}, index int, values ...interface{}) (ne
 
 // SliceDelete deletes an element at `index` and returns the new slice.
 // It does nothing if the given `index` is invalid.
+//
+// Note that the returned slice shares the backing array with `slice`: deleting the first or last
+// element returns a sub-slice, and deleting any other element shifts the elements of `slice` in place.
+// Use the generic function Delete if the given `slice` should be kept unchanged.
 func SliceDelete(slice []interface{}, index int) (newSlice []interface{}) {
 	if index < 0 || index >= len(slice) {
 		return slice
@@ -89,6 +95,43 @@ func SliceToMap(slice interface{}) map[string]interface{} {
 	return nil
 }
 
+// SliceToStruct converts slice type variable `slice` of which the items are keys and values
+// to struct that `pointer` points to, using conv.Struct.
+// Different from SliceToMap, it returns error if the length of `slice` is not an even number.
+// Eg:
+// ["Name", "john", "Age", 18] => {Name: "john", Age: 18}
+func SliceToStruct(slice interface{}, pointer interface{}) error {
+	var reflectValue = toReflectValue(slice)
+	for reflectValue.Kind() == reflect.Ptr && !reflectValue.IsNil() {
+		reflectValue = reflectValue.Elem()
+	}
+	switch reflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+	default:
+		var typeName = "nil"
+		if reflectValue.IsValid() {
+			typeName = reflectValue.Type().String()
+		}
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid parameter type "%s", should be type of slice/array`,
+			typeName,
+		)
+	}
+	if reflectValue.Len()%2 != 0 {
+		return errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid slice length %d, should be an even number`,
+			reflectValue.Len(),
+		)
+	}
+	data := make(map[string]interface{}, reflectValue.Len()/2)
+	for i := 0; i < reflectValue.Len(); i += 2 {
+		data[conv.String(reflectValue.Index(i).Interface())] = reflectValue.Index(i + 1).Interface()
+	}
+	return conv.Struct(data, pointer)
+}
+
 // SliceToMapWithColumnAsKey converts slice type variable `slice` to `map[interface{}]interface{}`
 // The value of specified column use as the key for returned map.
 // Eg:
@@ -0,0 +1,196 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+// Note that all functions in this file never change the given slices, which means the
+// returned slices always have their own backing array and never alias the parameters.
+// It is safe to modify the returned slices without affecting the given ones, and vice versa.
+
+// Pair is a pair of values, mainly used by function Zip.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Insert inserts `values` at `index` of `slice` and returns a new slice.
+// The `index` can be in range [0, len(slice)], in which len(slice) means appending.
+// It returns a copy of `slice` if the given `index` is invalid.
+func Insert[T any](slice []T, index int, values ...T) []T {
+	if index < 0 || index > len(slice) {
+		return cloneSlice(slice)
+	}
+	newSlice := make([]T, len(slice)+len(values))
+	copy(newSlice, slice[:index])
+	copy(newSlice[index:], values)
+	copy(newSlice[index+len(values):], slice[index:])
+	return newSlice
+}
+
+// Delete deletes the element at `index` of `slice` and returns a new slice.
+// It returns a copy of `slice` if the given `index` is invalid.
+//
+// Unlike SliceDelete, it never changes the backing array of `slice`.
+func Delete[T any](slice []T, index int) []T {
+	if index < 0 || index >= len(slice) {
+		return cloneSlice(slice)
+	}
+	newSlice := make([]T, 0, len(slice)-1)
+	newSlice = append(newSlice, slice[:index]...)
+	return append(newSlice, slice[index+1:]...)
+}
+
+// DeleteFunc returns a new slice containing the elements of `slice` of which `del` returns false.
+func DeleteFunc[T any](slice []T, del func(T) bool) []T {
+	newSlice := make([]T, 0, len(slice))
+	for _, v := range slice {
+		if !del(v) {
+			newSlice = append(newSlice, v)
+		}
+	}
+	return newSlice
+}
+
+// Chunk splits `slice` into multiple slices of which the length is `size`.
+// The last chunk may be shorter than `size`. It returns nil if `size` <= 0.
+// Eg: Chunk([1, 2, 3, 4, 5], 2) => [[1, 2], [3, 4], [5]]
+func Chunk[T any](slice []T, size int) [][]T {
+	if size <= 0 {
+		return nil
+	}
+	chunks := make([][]T, 0, (len(slice)+size-1)/size)
+	for i := 0; i < len(slice); i += size {
+		end := i + size
+		if end > len(slice) {
+			end = len(slice)
+		}
+		chunks = append(chunks, cloneSlice(slice[i:end]))
+	}
+	return chunks
+}
+
+// Partition splits `slice` into two slices, of which `matched` contains the elements that
+// `fn` returns true and `unmatched` contains the rest. The element order is kept.
+func Partition[T any](slice []T, fn func(T) bool) (matched, unmatched []T) {
+	matched = make([]T, 0)
+	unmatched = make([]T, 0)
+	for _, v := range slice {
+		if fn(v) {
+			matched = append(matched, v)
+		} else {
+			unmatched = append(unmatched, v)
+		}
+	}
+	return
+}
+
+// Unique returns a new slice containing the unique elements of `slice`.
+// It keeps the first occurrence of each element and the element order.
+func Unique[T comparable](slice []T) []T {
+	return UniqueBy(slice, func(v T) T { return v })
+}
+
+// UniqueBy acts as Unique, but it determines the uniqueness by the key that `key` returns.
+func UniqueBy[T any, K comparable](slice []T, key func(T) K) []T {
+	var (
+		newSlice = make([]T, 0, len(slice))
+		seen     = make(map[K]struct{}, len(slice))
+	)
+	for _, v := range slice {
+		k := key(v)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		newSlice = append(newSlice, v)
+	}
+	return newSlice
+}
+
+// Difference returns a new slice containing the elements of `slice` that are not in `others`.
+// The element order of `slice` is kept, and the duplicated elements of `slice` are kept.
+func Difference[T comparable](slice []T, others ...[]T) []T {
+	excluded := sliceToSet(others...)
+	newSlice := make([]T, 0, len(slice))
+	for _, v := range slice {
+		if _, ok := excluded[v]; !ok {
+			newSlice = append(newSlice, v)
+		}
+	}
+	return newSlice
+}
+
+// Intersect returns a new slice containing the unique elements of `slice`
+// that are also in all `others`. The element order of `slice` is kept.
+func Intersect[T comparable](slice []T, others ...[]T) []T {
+	sets := make([]map[T]struct{}, len(others))
+	for i, other := range others {
+		sets[i] = sliceToSet(other)
+	}
+	newSlice := make([]T, 0)
+	for _, v := range Unique(slice) {
+		contained := true
+		for _, set := range sets {
+			if _, ok := set[v]; !ok {
+				contained = false
+				break
+			}
+		}
+		if contained {
+			newSlice = append(newSlice, v)
+		}
+	}
+	return newSlice
+}
+
+// Union returns a new slice containing the unique elements of all `slices`,
+// in order of their first occurrence.
+func Union[T comparable](slices ...[]T) []T {
+	return Unique(Flatten(slices))
+}
+
+// Flatten concatenates all elements of `slices` into a new slice.
+// Eg: Flatten([[1, 2], [3], [4, 5]]) => [1, 2, 3, 4, 5]
+func Flatten[T any](slices [][]T) []T {
+	length := 0
+	for _, s := range slices {
+		length += len(s)
+	}
+	newSlice := make([]T, 0, length)
+	for _, s := range slices {
+		newSlice = append(newSlice, s...)
+	}
+	return newSlice
+}
+
+// Zip pairs the elements of `a` and `b` with the same index.
+// The length of returned slice is the shorter length of `a` and `b`.
+// Eg: Zip([1, 2, 3], ["a", "b"]) => [{1, "a"}, {2, "b"}]
+func Zip[A, B any](a []A, b []B) []Pair[A, B] {
+	length := len(a)
+	if len(b) < length {
+		length = len(b)
+	}
+	pairs := make([]Pair[A, B], length)
+	for i := 0; i < length; i++ {
+		pairs[i] = Pair[A, B]{First: a[i], Second: b[i]}
+	}
+	return pairs
+}
+
+func cloneSlice[T any](slice []T) []T {
+	newSlice := make([]T, len(slice))
+	copy(newSlice, slice)
+	return newSlice
+}
+
+func sliceToSet[T comparable](slices ...[]T) map[T]struct{} {
+	set := make(map[T]struct{})
+	for _, s := range slices {
+		for _, v := range s {
+			set[v] = struct{}{}
+		}
+	}
+	return set
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// isSliceAliased checks whether any element of `result` is in the backing array of `slice`,
+// including its spare capacity.
+func isSliceAliased[T any](slice, result []T) bool {
+	if cap(slice) == 0 || len(result) == 0 {
+		return false
+	}
+	var (
+		size  = unsafe.Sizeof(slice[:1][0])
+		start = uintptr(unsafe.Pointer(&slice[:1][0]))
+		end   = start + uintptr(cap(slice))*size
+	)
+	for i := range result {
+		if address := uintptr(unsafe.Pointer(&result[i])); address >= start && address < end {
+			return true
+		}
+	}
+	return false
+}
+
+// newSliceWithSpare returns a slice of `values` with spare capacity, so that appending to it in
+// place would be detected.
+func newSliceWithSpare(values ...int) []int {
+	return append(make([]int, 0, len(values)+4), values...)
+}
+
+func TestSliceGeneric_NoAliasing(t *testing.T) {
+	var cases = []struct {
+		name   string
+		call   func(s []int) [][]int
+		expect [][]int
+	}{
+		{
+			name:   "Insert middle",
+			call:   func(s []int) [][]int { return [][]int{Insert(s, 1, 9)} },
+			expect: [][]int{{1, 9, 2, 3}},
+		},
+		{
+			name:   "Insert end",
+			call:   func(s []int) [][]int { return [][]int{Insert(s, 3, 9)} },
+			expect: [][]int{{1, 2, 3, 9}},
+		},
+		{
+			name:   "Insert invalid index",
+			call:   func(s []int) [][]int { return [][]int{Insert(s, 5, 9)} },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name:   "Delete first",
+			call:   func(s []int) [][]int { return [][]int{Delete(s, 0)} },
+			expect: [][]int{{2, 3}},
+		},
+		{
+			name:   "Delete last",
+			call:   func(s []int) [][]int { return [][]int{Delete(s, 2)} },
+			expect: [][]int{{1, 2}},
+		},
+		{
+			name:   "Delete invalid index",
+			call:   func(s []int) [][]int { return [][]int{Delete(s, -1)} },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name:   "DeleteFunc none deleted",
+			call:   func(s []int) [][]int { return [][]int{DeleteFunc(s, func(int) bool { return false })} },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name:   "Chunk",
+			call:   func(s []int) [][]int { return Chunk(s, 2) },
+			expect: [][]int{{1, 2}, {3}},
+		},
+		{
+			name:   "Chunk single",
+			call:   func(s []int) [][]int { return Chunk(s, 5) },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name: "Partition",
+			call: func(s []int) [][]int {
+				matched, unmatched := Partition(s, func(v int) bool { return v != 2 })
+				return [][]int{matched, unmatched}
+			},
+			expect: [][]int{{1, 3}, {2}},
+		},
+		{
+			name:   "Unique",
+			call:   func(s []int) [][]int { return [][]int{Unique(s)} },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name:   "UniqueBy",
+			call:   func(s []int) [][]int { return [][]int{UniqueBy(s, func(v int) int { return v })} },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name:   "Difference",
+			call:   func(s []int) [][]int { return [][]int{Difference(s)} },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name:   "Intersect",
+			call:   func(s []int) [][]int { return [][]int{Intersect(s, []int{1, 2, 3})} },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name:   "Union",
+			call:   func(s []int) [][]int { return [][]int{Union(s)} },
+			expect: [][]int{{1, 2, 3}},
+		},
+		{
+			name:   "Flatten",
+			call:   func(s []int) [][]int { return [][]int{Flatten([][]int{s})} },
+			expect: [][]int{{1, 2, 3}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				slice   = newSliceWithSpare(1, 2, 3)
+				results = c.call(slice)
+			)
+			if !reflect.DeepEqual(results, c.expect) {
+				t.Fatalf("expect %v, got %v", c.expect, results)
+			}
+			for i, result := range results {
+				if isSliceAliased(slice, result) {
+					t.Fatalf("result %d shares the backing array of the given slice", i)
+				}
+				// Changing the result should never affect the given slice.
+				for j := range result {
+					result[j] = -1
+				}
+			}
+			if !reflect.DeepEqual(slice, []int{1, 2, 3}) {
+				t.Fatalf("the given slice is changed: %v", slice)
+			}
+			if spare := slice[len(slice):cap(slice)]; !reflect.DeepEqual(spare, make([]int, len(spare))) {
+				t.Fatalf("the spare capacity of the given slice is changed: %v", spare)
+			}
+		})
+	}
+}
+
+func TestChunk_Independent(t *testing.T) {
+	var chunks = Chunk([]int{1, 2, 3, 4}, 2)
+	// Appending to a chunk should never overwrite the next chunk.
+	_ = append(chunks[0], 9)
+	if !reflect.DeepEqual(chunks[1], []int{3, 4}) {
+		t.Fatalf("the next chunk is changed: %v", chunks[1])
+	}
+}
+
+func TestZip_NoAliasing(t *testing.T) {
+	var (
+		a     = []int{1, 2}
+		b     = []string{"a", "b", "c"}
+		pairs = Zip(a, b)
+	)
+	if !reflect.DeepEqual(pairs, []Pair[int, string]{{1, "a"}, {2, "b"}}) {
+		t.Fatalf("unexpected pairs: %v", pairs)
+	}
+	pairs[0].First = 9
+	if a[0] != 1 {
+		t.Fatalf("the given slice is changed: %v", a)
+	}
+}
+
+func TestSliceDelete_InPlace(t *testing.T) {
+	// Deleting a middle element shifts the elements of the given slice in place.
+	var (
+		slice  = []interface{}{1, 2, 3, 4}
+		result = SliceDelete(slice, 1)
+	)
+	if !reflect.DeepEqual(result, []interface{}{1, 3, 4}) {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if !reflect.DeepEqual(slice, []interface{}{1, 3, 4, 4}) {
+		t.Fatalf("expect the given slice shifted in place, got %v", slice)
+	}
+	// Deleting the first or last element returns a sub-slice of the given slice.
+	slice = []interface{}{1, 2, 3}
+	if result = SliceDelete(slice, 0); &result[0] != &slice[1] {
+		t.Fatal("expect the result of deleting the first element to be a sub-slice")
+	}
+	if result = SliceDelete(slice, 2); &result[0] != &slice[0] || len(result) != 2 {
+		t.Fatal("expect the result of deleting the last element to be a sub-slice")
+	}
+	// Invalid index returns the given slice itself.
+	if result = SliceDelete(slice, 3); &result[0] != &slice[0] || len(result) != 3 {
+		t.Fatal("expect the given slice returned for invalid index")
+	}
+}
@@ -5,12 +5,15 @@
 package utils
 
 import (
+	"os"
 	"reflect"
+	"regexp"
 
 	"github.com/gocarp/codes"
 	"github.com/gocarp/errors"
-	"github.com/gocarp/go/structs"
+	"github.com/gocarp/helpers/json"
 	"github.com/gocarp/utils/conv"
+	"github.com/gocarp/utils/tag"
 )
 
 // StructToSlice converts struct to slice of which all keys and values are its items.
@@ -38,8 +41,44 @@ func StructToSlice(data interface{}) []interface{} {
 	return nil
 }
 
-// FillStructWithDefault fills  attributes of pointed struct with tag value from `default/d` tag .
-// The parameter `structPtr` should be either type of *struct/[]*struct.
+// StructToSliceOrdered converts struct to slice of which all keys and values are its items,
+// in declaration order of the attributes.
+// The optional parameter `option` specifies the naming and order of the keys, and it uses the tag
+// names in tag.StructTagPriority of exported attributes in default, like StructToSlice.
+// Eg: {"K1": "v1", "K2": "v2"} => ["K1", "v1", "K2", "v2"]
+func StructToSliceOrdered(data interface{}, option ...KeysOption) []interface{} {
+	var usedOption = KeysOption{UseTag: true, ExportedOnly: true}
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	var reflectValue = toReflectValue(data)
+	for reflectValue.Kind() == reflect.Ptr && !reflectValue.IsNil() {
+		reflectValue = reflectValue.Elem()
+	}
+	if reflectValue.Kind() != reflect.Struct {
+		return nil
+	}
+	var (
+		keys, values = KeyValues(reflectValue, usedOption)
+		array        = make([]interface{}, 0, len(keys)*2)
+	)
+	for i, key := range keys {
+		array = append(array, key, values[i])
+	}
+	return array
+}
+
+// FillStructWithDefault fills attributes of pointed struct with tag value from `default/d` tag.
+// The parameter `structPtr` should be either type of *struct/[]*struct/[]struct/*[]*struct.
+//
+// It only fills the attributes of empty value, and the default value can be:
+//  1. The string that can be converted to the attribute type, eg: `d:"100"`;
+//  2. The JSON string for attributes of slice/map/array type, eg: `d:"[1,2,3]"` or `d:"{\"k\":\"v\"}"`;
+//  3. The string containing tag variables like `{name}`, which are replaced using tag.Parse;
+//  4. The string containing environment variables like `${NAME}`, which are replaced with their values.
+//
+// The nested struct attributes are filled recursively, and the nil pointer attributes of struct are
+// allocated only if any of their children attributes is filled with default value.
 func FillStructWithDefault(structPtr interface{}) error {
 	var (
 		reflectValue reflect.Value
@@ -51,15 +90,17 @@ func FillStructWithDefault(structPtr interface{}) error {
 	}
 	switch reflectValue.Kind() {
 	case reflect.Ptr:
-		// Nothing to do.
-	case reflect.Array, reflect.Slice:
-		if reflectValue.Elem().Kind() != reflect.Ptr {
-			return errors.NewCodef(
+		if reflectValue.IsNil() {
+			return errors.NewCode(
 				codes.CodeInvalidParameter,
-				`invalid parameter "%s", the element of slice should be type of pointer of struct, but given "%s"`,
-				reflectValue.Type().String(), reflectValue.Elem().Type().String(),
+				`the pointed struct object should not be nil`,
 			)
 		}
+		for reflectValue.Kind() == reflect.Ptr && !reflectValue.IsNil() {
+			reflectValue = reflectValue.Elem()
+		}
+	case reflect.Array, reflect.Slice:
+		// Nothing to do.
 	default:
 		return errors.NewCodef(
 			codes.CodeInvalidParameter,
@@ -67,42 +108,208 @@ func FillStructWithDefault(structPtr interface{}) error {
 			reflectValue.Type().String(),
 		)
 	}
-	if reflectValue.IsNil() {
+	filler := &defaultFiller{
+		visited:    make(map[uintptr]struct{}),
+		allocating: make(map[reflect.Type]struct{}),
+	}
+	switch reflectValue.Kind() {
+	case reflect.Struct:
+		if !reflectValue.CanSet() {
+			return errors.NewCodef(
+				codes.CodeInvalidParameter,
+				`invalid parameter "%s", the struct should be settable`,
+				reflectValue.Type().String(),
+			)
+		}
+		_, err := filler.fillStruct(reflectValue)
+		return err
+
+	case reflect.Array, reflect.Slice:
+		var elemType = reflectValue.Type().Elem()
+		for elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() != reflect.Struct {
+			return errors.NewCodef(
+				codes.CodeInvalidParameter,
+				`invalid parameter "%s", the element of slice should be type of struct or pointer of struct, but given "%s"`,
+				reflectValue.Type().String(), reflectValue.Type().Elem().String(),
+			)
+		}
+		if reflectValue.Kind() == reflect.Array && !reflectValue.CanSet() {
+			return errors.NewCodef(
+				codes.CodeInvalidParameter,
+				`invalid parameter "%s", the array should be given by pointer`,
+				reflectValue.Type().String(),
+			)
+		}
+		_, err := filler.fillSlice(reflectValue)
+		return err
+
+	case reflect.Ptr:
 		return errors.NewCode(
 			codes.CodeInvalidParameter,
 			`the pointed struct object should not be nil`,
 		)
-	}
-	if !reflectValue.Elem().IsValid() {
-		return errors.NewCode(
+
+	default:
+		return errors.NewCodef(
 			codes.CodeInvalidParameter,
-			`the pointed struct object should be valid`,
+			`invalid parameter "%s", should be type of pointer of struct`,
+			reflectValue.Type().String(),
 		)
 	}
-	fields, err := structs.Fields(structs.FieldsInput{
-		Pointer:         reflectValue,
-		RecursiveOption: structs.RecursiveOptionEmbedded,
-	})
-	if err != nil {
-		return err
-	}
-	for _, field := range fields {
-		if field.OriginalKind() == reflect.Struct {
-			err := FillStructWithDefault(field.OriginalValue().Addr())
-			if err != nil {
-				return err
+}
+
+// defaultEnvRegex matches the environment variables like `${NAME}` in default values.
+var defaultEnvRegex = regexp.MustCompile(`\$\{(\w+)\}`)
+
+type defaultFiller struct {
+	visited    map[uintptr]struct{}      // Visited struct pointers, for cycle detection.
+	allocating map[reflect.Type]struct{} // Struct types being allocated, for recursive types.
+}
+
+// fillStruct fills the attributes of settable struct `structValue`,
+// it returns true if any attribute is filled with default value.
+func (f *defaultFiller) fillStruct(structValue reflect.Value) (filled bool, err error) {
+	var structType = structValue.Type()
+	for i := 0; i < structValue.NumField(); i++ {
+		var (
+			field      = structType.Field(i)
+			fieldValue = structValue.Field(i)
+			ok         bool
+		)
+		if !fieldValue.CanSet() {
+			// The unexported embedded struct, of which the exported attributes are still settable.
+			if field.Anonymous && fieldValue.Kind() == reflect.Struct {
+				if ok, err = f.fillStruct(fieldValue); err != nil {
+					return false, err
+				}
+				filled = filled || ok
 			}
 			continue
 		}
+		if defaultValue := getDefaultTagValue(field); defaultValue != "" {
+			if !fieldValue.IsZero() {
+				continue
+			}
+			if err = setDefaultValue(fieldValue, defaultValue); err != nil {
+				return false, errors.Wrapf(err, `set default value for attribute "%s" failed`, field.Name)
+			}
+			filled = true
+			continue
+		}
+		switch fieldValue.Kind() {
+		case reflect.Struct:
+			ok, err = f.fillStruct(fieldValue)
+
+		case reflect.Ptr:
+			ok, err = f.fillPointer(fieldValue)
+
+		case reflect.Slice, reflect.Array:
+			ok, err = f.fillSlice(fieldValue)
+
+		default:
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		filled = filled || ok
+	}
+	return filled, nil
+}
+
+// fillPointer fills the struct that `pointerValue` points to.
+// If `pointerValue` is nil, it allocates the struct only if any attribute of it is filled.
+func (f *defaultFiller) fillPointer(pointerValue reflect.Value) (bool, error) {
+	var elemType = pointerValue.Type().Elem()
+	if elemType.Kind() != reflect.Struct {
+		return false, nil
+	}
+	if !pointerValue.IsNil() {
+		var pointer = pointerValue.Pointer()
+		if _, ok := f.visited[pointer]; ok {
+			return false, nil
+		}
+		f.visited[pointer] = struct{}{}
+		return f.fillStruct(pointerValue.Elem())
+	}
+	// It does not allocate the recursive type again, or else it never ends.
+	if _, ok := f.allocating[elemType]; ok {
+		return false, nil
+	}
+	f.allocating[elemType] = struct{}{}
+	defer delete(f.allocating, elemType)
+	var newValue = reflect.New(elemType)
+	filled, err := f.fillStruct(newValue.Elem())
+	if err != nil || !filled {
+		return false, err
+	}
+	pointerValue.Set(newValue)
+	return true, nil
+}
 
-		if defaultValue := field.TagDefault(); defaultValue != "" {
-			if field.IsEmpty() {
-				field.Value.Set(reflect.ValueOf(
-					conv.ConvertWithRefer(defaultValue, field.Value),
-				))
+// fillSlice fills the struct elements of `sliceValue`, the nil pointer elements are ignored.
+func (f *defaultFiller) fillSlice(sliceValue reflect.Value) (filled bool, err error) {
+	var elemType = sliceValue.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return false, nil
+	}
+	var ok bool
+	for i := 0; i < sliceValue.Len(); i++ {
+		var elemValue = sliceValue.Index(i)
+		for elemValue.Kind() == reflect.Ptr && elemValue.Type().Elem().Kind() == reflect.Ptr {
+			if elemValue.IsNil() {
+				break
+			}
+			elemValue = elemValue.Elem()
+		}
+		switch elemValue.Kind() {
+		case reflect.Struct:
+			ok, err = f.fillStruct(elemValue)
+		case reflect.Ptr:
+			if elemValue.IsNil() {
+				continue
 			}
+			ok, err = f.fillPointer(elemValue)
+		}
+		if err != nil {
+			return false, err
 		}
+		filled = filled || ok
+	}
+	return filled, nil
+}
+
+// getDefaultTagValue retrieves the default value from tag `default/d` of struct attribute.
+func getDefaultTagValue(field reflect.StructField) string {
+	if value := field.Tag.Get(tag.Default); value != "" {
+		return value
 	}
+	return field.Tag.Get(tag.DefaultShort)
+}
 
+// setDefaultValue parses `defaultValue` and sets it to `fieldValue`.
+func setDefaultValue(fieldValue reflect.Value, defaultValue string) error {
+	defaultValue = defaultEnvRegex.ReplaceAllStringFunc(defaultValue, func(s string) string {
+		return os.Getenv(s[2 : len(s)-1])
+	})
+	defaultValue = tag.Parse(defaultValue)
+	switch fieldValue.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		if json.Valid([]byte(defaultValue)) {
+			var newValue = reflect.New(fieldValue.Type())
+			if err := json.UnmarshalUseNumber([]byte(defaultValue), newValue.Interface()); err != nil {
+				return err
+			}
+			fieldValue.Set(newValue.Elem())
+			return nil
+		}
+	}
+	fieldValue.Set(reflect.ValueOf(conv.ConvertWithRefer(defaultValue, fieldValue)))
 	return nil
 }
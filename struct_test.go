@@ -0,0 +1,161 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gocarp/utils/tag"
+)
+
+func TestFillStructWithDefault_JSON(t *testing.T) {
+	type Config struct {
+		Ports   []int             `d:"[80, 443]"`
+		Labels  map[string]string `d:"{\"env\": \"dev\"}"`
+		Pair    [2]string         `d:"[\"a\", \"b\"]"`
+		Names   []string          `d:"x"`
+		Existed []int             `d:"[1]"`
+	}
+	var config = Config{Existed: []int{9}}
+	if err := FillStructWithDefault(&config); err != nil {
+		t.Fatal(err)
+	}
+	var expect = Config{
+		Ports:   []int{80, 443},
+		Labels:  map[string]string{"env": "dev"},
+		Pair:    [2]string{"a", "b"},
+		Names:   []string{"x"},
+		Existed: []int{9},
+	}
+	if !reflect.DeepEqual(config, expect) {
+		t.Fatalf("\nexpect: %#v\nactual: %#v", expect, config)
+	}
+}
+
+func TestFillStructWithDefault_Env(t *testing.T) {
+	t.Setenv("FILL_DEFAULT_HOST", "db.local")
+	t.Setenv("FILL_DEFAULT_PORT", "5432")
+	type Config struct {
+		DSN     string `default:"postgres://${FILL_DEFAULT_HOST}:${FILL_DEFAULT_PORT}"`
+		Port    int    `d:"${FILL_DEFAULT_PORT}"`
+		Missing string `d:"[${FILL_DEFAULT_MISSING}]"`
+	}
+	var config Config
+	if err := FillStructWithDefault(&config); err != nil {
+		t.Fatal(err)
+	}
+	var expect = Config{DSN: "postgres://db.local:5432", Port: 5432, Missing: "[]"}
+	if config != expect {
+		t.Fatalf("\nexpect: %#v\nactual: %#v", expect, config)
+	}
+}
+
+func TestFillStructWithDefault_TagParse(t *testing.T) {
+	tag.Set("fillDefaultName", "app")
+	type Config struct {
+		Name string `d:"{fillDefaultName}-service"`
+	}
+	var config Config
+	if err := FillStructWithDefault(&config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app-service" {
+		t.Fatalf(`expect "app-service", got "%s"`, config.Name)
+	}
+}
+
+func TestFillStructWithDefault_PointerAllocation(t *testing.T) {
+	type Filled struct {
+		Level string `d:"info"`
+	}
+	type Unfilled struct {
+		Level string
+	}
+	type Node struct {
+		Value int `d:"1"`
+		Next  *Node
+	}
+	type Config struct {
+		Log      *Filled
+		Other    *Unfilled
+		Existed  *Filled
+		Node     *Node
+		NotField *int
+	}
+	var config = Config{Existed: &Filled{Level: "debug"}}
+	if err := FillStructWithDefault(&config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Log == nil || config.Log.Level != "info" {
+		t.Fatalf("expect Log allocated and filled, got %#v", config.Log)
+	}
+	if config.Other != nil {
+		t.Fatalf("expect Other not allocated as no attribute is filled, got %#v", config.Other)
+	}
+	if config.Existed.Level != "debug" {
+		t.Fatalf(`expect Existed kept "debug", got "%s"`, config.Existed.Level)
+	}
+	// The recursive type is allocated only once.
+	if config.Node == nil || config.Node.Value != 1 || config.Node.Next != nil {
+		t.Fatalf("expect Node allocated once, got %#v", config.Node)
+	}
+	if config.NotField != nil {
+		t.Fatalf("expect non-struct pointer not allocated, got %v", config.NotField)
+	}
+}
+
+func TestFillStructWithDefault_Slices(t *testing.T) {
+	type Item struct {
+		Name  string `d:"item"`
+		Count int    `d:"1"`
+	}
+	t.Run("[]struct", func(t *testing.T) {
+		var items = []Item{{}, {Name: "x"}}
+		if err := FillStructWithDefault(items); err != nil {
+			t.Fatal(err)
+		}
+		var expect = []Item{{Name: "item", Count: 1}, {Name: "x", Count: 1}}
+		if !reflect.DeepEqual(items, expect) {
+			t.Fatalf("\nexpect: %#v\nactual: %#v", expect, items)
+		}
+	})
+	t.Run("[]*struct", func(t *testing.T) {
+		var items = []*Item{{}, nil, {Count: 5}}
+		if err := FillStructWithDefault(items); err != nil {
+			t.Fatal(err)
+		}
+		if *items[0] != (Item{Name: "item", Count: 1}) || items[1] != nil || *items[2] != (Item{Name: "item", Count: 5}) {
+			t.Fatalf("unexpected items: %#v, %#v, %#v", items[0], items[1], items[2])
+		}
+	})
+	t.Run("*[]*struct", func(t *testing.T) {
+		var items = []*Item{{}}
+		if err := FillStructWithDefault(&items); err != nil {
+			t.Fatal(err)
+		}
+		if *items[0] != (Item{Name: "item", Count: 1}) {
+			t.Fatalf("unexpected item: %#v", items[0])
+		}
+	})
+	t.Run("invalid element", func(t *testing.T) {
+		if err := FillStructWithDefault([]int{1}); err == nil {
+			t.Fatal("expect error for slice of non-struct")
+		}
+	})
+}
+
+func TestFillStructWithDefault_Invalid(t *testing.T) {
+	type Config struct {
+		Name string `d:"x"`
+	}
+	var config *Config
+	if err := FillStructWithDefault(config); err == nil {
+		t.Fatal("expect error for nil pointer")
+	}
+	if err := FillStructWithDefault(Config{}); err == nil {
+		t.Fatal("expect error for non-pointer struct")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2022-2024 The Focela Authors, All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/gocarp/codes"
+	"github.com/gocarp/errors"
+	"github.com/gocarp/go/structs"
+)
+
+// StructFieldsRecursive specifies the way retrieving the attributes recursively for function StructFields.
+type StructFieldsRecursive int
+
+const (
+	StructFieldsRecursiveNone     StructFieldsRecursive = iota // No recursively retrieving, the embedded struct is retrieved as single attribute.
+	StructFieldsRecursiveEmbedded                              // Recursively retrieving the attributes of embedded struct instead of itself.
+	StructFieldsRecursiveAll                                   // Recursively retrieving the attributes of embedded struct and nested struct attributes.
+)
+
+// StructFieldsOption specifies the option for function StructFields.
+type StructFieldsOption struct {
+	// Recursive specifies the way retrieving the attributes recursively, it is StructFieldsRecursiveNone in default.
+	Recursive StructFieldsRecursive
+
+	// ExportedOnly specifies retrieving only the exported attributes.
+	ExportedOnly bool
+}
+
+// StructField is the metadata of struct attribute retrieved by function StructFields.
+// Note that the Index and Tags are shared among calls for the same type, which should not be modified.
+type StructField struct {
+	Name     string            // Attribute name.
+	Path     string            // Dot-separated attribute path from the root struct, eg: "User.Name".
+	Index    []int             // Index sequence from the root struct, see reflect.Value.FieldByIndex.
+	Type     reflect.Type      // Attribute type.
+	Kind     reflect.Kind      // Kind of attribute type.
+	Tags     map[string]string // Attribute tags, key is the tag name and value is the tag value.
+	Embedded bool              // Whether the attribute is an embedded attribute.
+	Exported bool              // Whether the attribute is exported.
+	root     reflect.Value     // The root struct value, it is invalid if StructFields is called with type.
+}
+
+// structFieldsCacheKey is the cache key for attributes metadata of struct type with option.
+type structFieldsCacheKey struct {
+	Type   reflect.Type
+	Option StructFieldsOption
+}
+
+// structFieldsCache caches the attributes metadata, the value is type of []StructField without root value.
+var structFieldsCache sync.Map
+
+// StructFields retrieves and returns the attributes metadata of struct `v` in declaration order.
+//
+// The parameter `v` can be type of struct/*struct/reflect.Value/reflect.Type, the value accessor
+// StructField.Value is unavailable if `v` is reflect.Type.
+//
+// The embedded struct is retrieved as single attribute in default, and it is replaced by its attributes
+// if option Recursive is StructFieldsRecursiveEmbedded. If option Recursive is StructFieldsRecursiveAll,
+// the attributes of nested struct attributes are also retrieved right after the nested attribute.
+// The metadata is cached for each struct type.
+func StructFields(v interface{}, option ...StructFieldsOption) ([]StructField, error) {
+	var (
+		usedOption   StructFieldsOption
+		reflectValue reflect.Value
+		reflectType  reflect.Type
+	)
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	switch r := v.(type) {
+	case reflect.Type:
+		reflectType = r
+	case reflect.Value:
+		reflectValue = r
+	default:
+		reflectValue = reflect.ValueOf(v)
+	}
+	if reflectValue.IsValid() {
+		for reflectValue.Kind() == reflect.Ptr || reflectValue.Kind() == reflect.Interface {
+			if reflectValue.IsNil() {
+				// The nil pointer still has type information.
+				reflectType = reflectValue.Type()
+				reflectValue = reflect.Value{}
+				break
+			}
+			reflectValue = reflectValue.Elem()
+		}
+		if reflectValue.IsValid() {
+			reflectType = reflectValue.Type()
+		}
+	}
+	for reflectType != nil && reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
+	}
+	if reflectType == nil || reflectType.Kind() != reflect.Struct {
+		return nil, errors.NewCodef(
+			codes.CodeInvalidParameter,
+			`invalid parameter type "%v", should be type of struct/*struct`,
+			reflectType,
+		)
+	}
+	var (
+		cacheKey = structFieldsCacheKey{Type: reflectType, Option: usedOption}
+		cached   []StructField
+	)
+	if v, ok := structFieldsCache.Load(cacheKey); ok {
+		cached = v.([]StructField)
+	} else {
+		cached = make([]StructField, 0)
+		doStructFields(reflectType, usedOption, "", nil, map[reflect.Type]struct{}{reflectType: {}}, &cached)
+		structFieldsCache.Store(cacheKey, cached)
+	}
+	var fields = make([]StructField, len(cached))
+	for i, field := range cached {
+		field.root = reflectValue
+		fields[i] = field
+	}
+	return fields, nil
+}
+
+// doStructFields retrieves the attributes metadata of `structType` into `fields`.
+// The parameter `parents` contains the types on the path, for recursive types.
+func doStructFields(
+	structType reflect.Type,
+	option StructFieldsOption,
+	parentPath string,
+	parentIndex []int,
+	parents map[reflect.Type]struct{},
+	fields *[]StructField,
+) {
+	for i := 0; i < structType.NumField(); i++ {
+		var (
+			field = structType.Field(i)
+			index = append(append([]int{}, parentIndex...), i)
+			path  = field.Name
+		)
+		if parentPath != "" {
+			path = parentPath + pathSeparator + field.Name
+		}
+		var nestedType = field.Type
+		if nestedType.Kind() == reflect.Ptr {
+			nestedType = nestedType.Elem()
+		}
+		var (
+			_, isParent = parents[nestedType]
+			isNested    = nestedType.Kind() == reflect.Struct && !isParent && hasExportedField(nestedType)
+		)
+		if isNested && field.Anonymous && option.Recursive != StructFieldsRecursiveNone {
+			// The embedded attributes are promoted, so they use the same path prefix as embedded struct.
+			parents[nestedType] = struct{}{}
+			doStructFields(nestedType, option, parentPath, index, parents, fields)
+			delete(parents, nestedType)
+			continue
+		}
+		if option.ExportedOnly && !field.IsExported() {
+			continue
+		}
+		*fields = append(*fields, StructField{
+			Name:     field.Name,
+			Path:     path,
+			Index:    index,
+			Type:     field.Type,
+			Kind:     field.Type.Kind(),
+			Tags:     structs.ParseTag(string(field.Tag)),
+			Embedded: field.Anonymous,
+			Exported: field.IsExported(),
+		})
+		if isNested && option.Recursive == StructFieldsRecursiveAll {
+			parents[nestedType] = struct{}{}
+			doStructFields(nestedType, option, path, index, parents, fields)
+			delete(parents, nestedType)
+		}
+	}
+}
+
+// Tag returns the tag value of `key`.
+func (f StructField) Tag(key string) string {
+	return f.Tags[key]
+}
+
+// Value returns the attribute value from the root struct.
+// It returns invalid reflect.Value if StructFields is called with type, or any pointer on the path is nil.
+// The returned value is settable if StructFields is called with pointer and the attribute is exported.
+func (f StructField) Value() reflect.Value {
+	var reflectValue = f.root
+	if !reflectValue.IsValid() {
+		return reflect.Value{}
+	}
+	for i, x := range f.Index {
+		if i > 0 {
+			for reflectValue.Kind() == reflect.Ptr {
+				if reflectValue.IsNil() {
+					return reflect.Value{}
+				}
+				reflectValue = reflectValue.Elem()
+			}
+		}
+		reflectValue = reflectValue.Field(x)
+	}
+	return reflectValue
+}
+
+// Interface returns the attribute value from the root struct as interface{}.
+// It returns nil if the value is unavailable or the attribute is unexported.
+func (f StructField) Interface() interface{} {
+	var reflectValue = f.Value()
+	if !reflectValue.IsValid() || !reflectValue.CanInterface() {
+		return nil
+	}
+	return reflectValue.Interface()
+}
@@ -43,6 +43,9 @@ const (
 	GConvShort        = "c"            // GCConvShort defines the converting target name for specified struct field.
 	Json              = "json"         // Json tag is supported by stdlib.
 	Security          = "security"     // Security defines scheme for authentication. Detail to see https://swagger.io/docs/specification/authentication/
+	Copy              = "copy"         // Copy tag for deep copy, value "-" skips the attribute and "shallow" copies it shallowly.
+	Dump              = "dump"         // Dump tag for dumping, value "-" redacts the attribute value.
+	Redact            = "redact"       // Redact tag for dumping and map converting, value "true" redacts the attribute value.
 	In                = "in"           // Swagger distinguishes between the following parameter types based on the parameter location. Detail to see https://swagger.io/docs/specification/describing-parameters/
 )
 
@@ -7,16 +7,42 @@ package utils
 
 import (
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/gocarp/utils/conv"
+	"github.com/gocarp/utils/tag"
 )
 
 const (
 	dumpIndent = `    `
 )
 
+// KeysOption specifies the option for functions Keys, Values and KeyValues.
+type KeysOption struct {
+	// UseTag specifies using the tag name as the key of struct attribute if the tag is found.
+	// Note that the attribute of tag name "-" is always ignored, no matter UseTag is true or not.
+	UseTag bool
+
+	// Tags specifies the priority tags for tag names, it is tag.StructTagPriority in default.
+	Tags []string
+
+	// ExportedOnly specifies ignoring the unexported attributes of struct.
+	ExportedOnly bool
+
+	// Sorted specifies returning the keys in ascending order, and the values in order of their keys.
+	// The numeric keys are sorted numerically and the string keys in natural order, like MapToSliceSorted.
+	// The struct attributes are in declaration order and the map keys are in random order if it is false.
+	Sorted bool
+}
+
 // Keys retrieves and returns the keys from given map or struct.
-func Keys(mapOrStruct interface{}) (keysOrAttrs []string) {
+// The optional parameter `option` specifies the naming and order of the keys, see KeysOption.
+func Keys(mapOrStruct interface{}, option ...KeysOption) (keysOrAttrs []string) {
+	if len(option) > 0 {
+		keysOrAttrs, _ = KeyValues(mapOrStruct, option[0])
+		return
+	}
 	keysOrAttrs = make([]string, 0)
 	if m, ok := mapOrStruct.(map[string]interface{}); ok {
 		for k := range m {
@@ -66,7 +92,12 @@ func Keys(mapOrStruct interface{}) (keysOrAttrs []string) {
 }
 
 // Values retrieves and returns the values from given map or struct.
-func Values(mapOrStruct interface{}) (values []interface{}) {
+// The optional parameter `option` specifies the order of the values, see KeysOption.
+func Values(mapOrStruct interface{}, option ...KeysOption) (values []interface{}) {
+	if len(option) > 0 {
+		_, values = KeyValues(mapOrStruct, option[0])
+		return
+	}
 	values = make([]interface{}, 0)
 	if m, ok := mapOrStruct.(map[string]interface{}); ok {
 		for _, v := range m {
@@ -109,3 +140,118 @@ func Values(mapOrStruct interface{}) (values []interface{}) {
 	}
 	return
 }
+
+// KeyValues retrieves and returns the keys and values from given map or struct,
+// in which the value at index i is the value of the key at index i.
+// The values of unexported struct attributes are nil as they cannot be retrieved.
+func KeyValues(mapOrStruct interface{}, option ...KeysOption) (keys []string, values []interface{}) {
+	var usedOption KeysOption
+	if len(option) > 0 {
+		usedOption = option[0]
+	}
+	if len(usedOption.Tags) == 0 {
+		usedOption.Tags = tag.StructTagPriority
+	}
+	keys = make([]string, 0)
+	values = make([]interface{}, 0)
+	var reflectValue = toReflectValue(mapOrStruct)
+	for reflectValue.Kind() == reflect.Ptr || reflectValue.Kind() == reflect.Interface {
+		if reflectValue.IsNil() {
+			if reflectValue.Kind() == reflect.Interface {
+				return
+			}
+			reflectValue = reflect.New(reflectValue.Type().Elem()).Elem()
+		} else {
+			reflectValue = reflectValue.Elem()
+		}
+	}
+	switch reflectValue.Kind() {
+	case reflect.Map:
+		var mapKeys = reflectValue.MapKeys()
+		if usedOption.Sorted {
+			mapKeys = sortedMapKeys(reflectValue)
+		}
+		for _, k := range mapKeys {
+			keys = append(keys, conv.String(k.Interface()))
+			values = append(values, reflectValueToInterface(reflectValue.MapIndex(k)))
+		}
+		return
+
+	case reflect.Struct:
+		doKeyValuesForStruct(reflectValue, usedOption, &keys, &values)
+		if usedOption.Sorted {
+			sort.Stable(keyValuesSorter{keys: keys, values: values})
+		}
+	}
+	return
+}
+
+// doKeyValuesForStruct retrieves the keys and values of struct `reflectValue`,
+// the attributes of embedded struct are retrieved as attributes of `reflectValue`.
+func doKeyValuesForStruct(reflectValue reflect.Value, option KeysOption, keys *[]string, values *[]interface{}) {
+	var reflectType = reflectValue.Type()
+	for i := 0; i < reflectValue.NumField(); i++ {
+		var (
+			fieldType  = reflectType.Field(i)
+			fieldValue = reflectValue.Field(i)
+			name       = fieldType.Name
+			tagName    = getKeyTagName(fieldType, option.Tags)
+		)
+		if tagName == "-" {
+			continue
+		}
+		if !option.UseTag {
+			tagName = ""
+		}
+		if fieldType.Anonymous && tagName == "" {
+			for fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					fieldValue = reflect.New(fieldValue.Type().Elem()).Elem()
+				} else {
+					fieldValue = fieldValue.Elem()
+				}
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				doKeyValuesForStruct(fieldValue, option, keys, values)
+				continue
+			}
+		}
+		if option.ExportedOnly && !fieldType.IsExported() {
+			continue
+		}
+		if tagName != "" {
+			name = tagName
+		}
+		*keys = append(*keys, name)
+		*values = append(*values, reflectValueToInterface(reflectValue.Field(i)))
+	}
+}
+
+// getKeyTagName returns the tag name of struct attribute `field` from the first found tag in `priorityTags`.
+func getKeyTagName(field reflect.StructField, priorityTags []string) string {
+	for _, tagName := range priorityTags {
+		if tagValue, ok := field.Tag.Lookup(tagName); ok {
+			return strings.TrimSpace(strings.Split(tagValue, ",")[0])
+		}
+	}
+	return ""
+}
+
+// keyValuesSorter sorts the keys and values together by keys.
+type keyValuesSorter struct {
+	keys   []string
+	values []interface{}
+}
+
+func (s keyValuesSorter) Len() int {
+	return len(s.keys)
+}
+
+func (s keyValuesSorter) Less(i, j int) bool {
+	return compareNaturalString(s.keys[i], s.keys[j]) < 0
+}
+
+func (s keyValuesSorter) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+}